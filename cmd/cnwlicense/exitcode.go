@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// Exit statuses. They are part of the command's interface: scripts branch on them.
+const (
+	exitOK          = 0
+	exitError       = 1 // unclassified failure
+	exitUsage       = 2 // invalid command line
+	exitInvalid     = 3 // license not found, rejected, or not valid for this machine
+	exitExpired     = 4 // license expired
+	exitInactive    = 5 // license suspended or revoked
+	exitSignature   = 6 // license file, signature or key invalid
+	exitLimits      = 7 // hardware limit exceeded
+	exitUnavailable = 8 // license server unreachable or rate limiting
+)
+
+// errLicenseInvalid reports a validation response with valid=false for a reason
+// that does not map to a more specific sentinel error.
+var errLicenseInvalid = errors.New("license is not valid")
+
+// errUntrustedKey reports a license file that was only checked against the public
+// key embedded in it, which anyone can replace along with the signature.
+var errUntrustedKey = errors.New("signature NOT verified against a trusted key (pass -public-key or set CNW_LICENSE_PUBLIC_KEY)")
+
+// exitCode maps err to an exit status using the SDK's sentinel errors.
+func exitCode(err error) int {
+	var ue *usageError
+	var se *cnwlicense.ServerError
+	var urlErr *url.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &ue):
+		return exitUsage
+	case errors.Is(err, cnwlicense.ErrLicenseExpired):
+		return exitExpired
+	case errors.Is(err, cnwlicense.ErrLicenseInactive), errors.Is(err, cnwlicense.ErrLicenseRevoked):
+		return exitInactive
+	case errors.Is(err, cnwlicense.ErrLicenseNotFound), errors.Is(err, errLicenseInvalid),
+		errors.Is(err, cnwlicense.ErrFingerprintMismatch), errors.Is(err, cnwlicense.ErrLicenseKeyMismatch),
+		errors.Is(err, cnwlicense.ErrLicenseNotYetValid), errors.Is(err, cnwlicense.ErrLicenseIssuedInFuture),
+		errors.Is(err, cnwlicense.ErrActivationLimit), errors.Is(err, cnwlicense.ErrClockTampered):
+		return exitInvalid
+	case errors.Is(err, cnwlicense.ErrSignatureInvalid), errors.Is(err, cnwlicense.ErrPublicKeyInvalid),
+		errors.Is(err, cnwlicense.ErrLicenseFileInvalid), errors.Is(err, cnwlicense.ErrKeyRetired),
+		errors.Is(err, errUntrustedKey):
+		return exitSignature
+	case errors.Is(err, cnwlicense.ErrCPULimitExceeded), errors.Is(err, cnwlicense.ErrNodeLimitExceeded):
+		return exitLimits
+	case errors.Is(err, cnwlicense.ErrRateLimited), errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &urlErr):
+		return exitUnavailable
+	case errors.As(err, &se) && se.StatusCode >= 500:
+		return exitUnavailable
+	}
+	return exitError
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+type fingerprintResult struct {
+	Fingerprint string                            `json:"fingerprint"`
+	Override    bool                              `json:"override"` // set by CNW_FINGERPRINT
+	Components  *cnwlicense.FingerprintComponents `json:"components"`
+}
+
+func runFingerprint(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("fingerprint", "", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	fp, err := cnwlicense.GenerateFingerprint()
+	if err != nil {
+		return err
+	}
+	components, err := cnwlicense.ReadFingerprintComponents()
+	if err != nil {
+		return err
+	}
+	res := fingerprintResult{
+		Fingerprint: fp,
+		Override:    os.Getenv("CNW_FINGERPRINT") != "",
+		Components:  components,
+	}
+	return emit(stdout, *asJSON, res, func(w io.Writer) {
+		field(w, "Fingerprint", res.Fingerprint)
+		if res.Override {
+			fmt.Fprintln(w, "Source:\tCNW_FINGERPRINT (components below are not used)")
+		}
+		field(w, "Hostname", components.Hostname)
+		field(w, "MAC addresses", list(components.MACAddresses))
+		field(w, "OS", components.OS)
+		field(w, "Arch", components.Arch)
+		field(w, "Machine ID", components.MachineID)
+	})
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/signer"
+)
+
+type keygenResult struct {
+	KeyID     string `json:"key_id,omitempty"`
+	PublicKey string `json:"public_key"`
+	KeyFile   string `json:"key_file"`
+}
+
+func runKeygen(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keygen", "", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	keyID := fs.String("id", "", "key ID written to signed files")
+	out := fs.String("o", "", "key file to write (required)")
+	force := fs.Bool("force", false, "overwrite an existing key file")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *out == "" {
+		return &usageError{msg: "missing -o"}
+	}
+	if _, err := os.Stat(*out); err == nil && !*force {
+		return fmt.Errorf("%s already exists (use -force to overwrite)", *out)
+	}
+
+	s, err := signer.Generate(signer.WithKeyID(*keyID))
+	if err != nil {
+		return err
+	}
+	if err := s.SaveKeyFile(*out); err != nil {
+		return err
+	}
+	res := keygenResult{KeyID: s.KeyID(), PublicKey: s.PublicKey(), KeyFile: *out}
+	return emit(stdout, *asJSON, res, func(w io.Writer) {
+		field(w, "Key file", res.KeyFile)
+		field(w, "Key ID", res.KeyID)
+		field(w, "Public key", res.PublicKey)
+	})
+}
+
+func runIssue(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("issue", "", stderr)
+	asJSON := fs.Bool("json", false, "print the issued license data as JSON (with -o)")
+	keyFile := fs.String("signing-key", "", "signing key file from keygen (required)")
+	licenseKey := fs.String("key", "", "license key (required unless -request is given)")
+	plan := fs.String("plan", "", "plan name")
+	company := fs.String("company", "", "company ID")
+	app := fs.String("app", "", "app ID")
+	expires := fs.String("expires", "", "expiry: RFC 3339 time, YYYY-MM-DD, or duration such as 30d or 720h (required)")
+	notBefore := fs.String("not-before", "", "start of validity: RFC 3339 time or YYYY-MM-DD")
+	request := fs.String("request", "", "offline activation request file; issues a license node-locked to it")
+	featuresFile := fs.String("features", "", "JSON file with a features object")
+	out := fs.String("o", "", "license file to write (default: stdout)")
+	var features, fingerprints stringList
+	fs.Var(&features, "feature", "feature as name=value; value is parsed as JSON if possible (repeatable)")
+	fs.Var(&fingerprints, "fingerprint", "node-lock to this machine fingerprint (repeatable)")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *keyFile == "" || *expires == "" {
+		return &usageError{msg: "missing -signing-key or -expires"}
+	}
+
+	now := clock.Now().UTC()
+	data := cnwlicense.OfflineLicenseData{
+		LicenseKey:   *licenseKey,
+		CompanyID:    *company,
+		AppID:        *app,
+		Plan:         *plan,
+		Features:     cnwlicense.Features{},
+		IssuedAt:     now,
+		Fingerprints: fingerprints,
+	}
+	var err error
+	if data.ExpiresAt, err = parseExpiry(*expires, now); err != nil {
+		return &usageError{msg: "-expires: " + err.Error()}
+	}
+	if *notBefore != "" {
+		t, err := parseTime(*notBefore)
+		if err != nil {
+			return &usageError{msg: "-not-before: " + err.Error()}
+		}
+		data.NotBefore = &t
+	}
+	if *request != "" {
+		if err := applyActivationRequest(&data, *request); err != nil {
+			return err
+		}
+	}
+	if data.LicenseKey == "" {
+		return &usageError{msg: "missing -key"}
+	}
+	if *featuresFile != "" {
+		raw, err := os.ReadFile(*featuresFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &data.Features); err != nil {
+			return fmt.Errorf("%s: %w", *featuresFile, err)
+		}
+		if data.Features == nil { // the file contained null
+			data.Features = cnwlicense.Features{}
+		}
+	}
+	for _, f := range features {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return &usageError{msg: fmt.Sprintf("-feature %q: expected name=value", f)}
+		}
+		data.Features[name] = featureValue(value)
+	}
+
+	s, err := signer.LoadKeyFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	file, err := s.Sign(data)
+	if err != nil {
+		return err
+	}
+	// Not MarshalIndent: it would re-indent the signed license bytes.
+	raw, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+	if *out == "" {
+		_, err := stdout.Write(raw)
+		return err
+	}
+	if err := os.WriteFile(*out, raw, 0o644); err != nil {
+		return err
+	}
+	return emit(stdout, *asJSON, data, func(w io.Writer) {
+		field(w, "License file", *out)
+		field(w, "License key", data.LicenseKey)
+		field(w, "Plan", data.Plan)
+		field(w, "Expires", formatTime(data.ExpiresAt))
+		field(w, "Fingerprints", list(data.Fingerprints))
+		field(w, "Signing key", s.KeyID())
+	})
+}
+
+// applyActivationRequest node-locks data to the machine in an offline activation
+// request file and binds it to the request's nonce.
+func applyActivationRequest(data *cnwlicense.OfflineLicenseData, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	req, err := cnwlicense.ParseOfflineActivationRequest(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if data.LicenseKey != "" && data.LicenseKey != req.LicenseKey {
+		return fmt.Errorf("%w: request is for %q", cnwlicense.ErrLicenseKeyMismatch, req.LicenseKey)
+	}
+	data.LicenseKey = req.LicenseKey
+	data.Fingerprints = append(data.Fingerprints, req.Fingerprint)
+	data.ActivationNonce = req.Nonce
+	return nil
+}
+
+// featureValue parses a -feature value as JSON (numbers, booleans, arrays,
+// quoted strings), and otherwise uses it as a plain string.
+func featureValue(s string) any {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	return s
+}
+
+// parseTime parses an RFC 3339 time or a YYYY-MM-DD date (midnight UTC).
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// parseExpiry parses an absolute time (see parseTime) or a duration from now,
+// which may use a "d" suffix for days.
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	if t, err := parseTime(s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, errors.New("expected a time, YYYY-MM-DD, or positive duration such as 30d")
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
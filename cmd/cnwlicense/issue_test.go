@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+func TestRun_KeygenIssue(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.json")
+	licensePath := filepath.Join(dir, "license.json")
+
+	code, stdout, stderr := runCLI("keygen", "-json", "-id", "emergency", "-o", keyPath)
+	if code != exitOK {
+		t.Fatalf("keygen: exit %d: %s", code, stderr)
+	}
+	var key keygenResult
+	if err := json.Unmarshal([]byte(stdout), &key); err != nil || key.PublicKey == "" || key.KeyID != "emergency" {
+		t.Fatalf("unexpected keygen output %q: %v", stdout, err)
+	}
+	if code, _, _ := runCLI("keygen", "-o", keyPath); code == exitOK {
+		t.Error("expected keygen to refuse to overwrite an existing key file")
+	}
+
+	code, _, stderr = runCLI("issue", "-signing-key", keyPath, "-key", "CNW-TEST-1234", "-plan", "enterprise",
+		"-expires", "30d", "-feature", "max_nodes=5", "-feature", "sso=true", "-feature", "tier=gold",
+		"-fingerprint", "node-fp", "-o", licensePath)
+	if code != exitOK {
+		t.Fatalf("issue: exit %d: %s", code, stderr)
+	}
+
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedKeys(cnwlicense.TrustedKey{ID: "emergency", PublicKey: key.PublicKey}),
+		cnwlicense.WithMachineFingerprint("node-fp"),
+	)
+	data, err := v.VerifyFile(licensePath)
+	if err != nil {
+		t.Fatalf("verify issued license: %v", err)
+	}
+	if data.LicenseKey != "CNW-TEST-1234" || data.Plan != "enterprise" || data.VerifiedKeyID != "emergency" {
+		t.Errorf("unexpected license: %+v", data)
+	}
+	if data.Features.Int("max_nodes", 0) != 5 || !data.Features.Bool("sso", false) || data.Features.String("tier", "") != "gold" {
+		t.Errorf("unexpected features: %v", data.Features)
+	}
+	if d := time.Until(data.ExpiresAt); d < 29*24*time.Hour || d > 31*24*time.Hour {
+		t.Errorf("expected expiry in 30 days, got %s", data.ExpiresAt)
+	}
+
+	// Without a matching fingerprint the node-locked file is rejected.
+	if code, _, _ := runCLI("verify", "-public-key", key.PublicKey, "-fingerprint", "other-fp", licensePath); code != exitInvalid {
+		t.Errorf("expected exit %d, got %d", exitInvalid, code)
+	}
+}
+
+func TestRun_IssueActivationResponse(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.json")
+	requestPath := filepath.Join(dir, "request.json")
+	if code, _, stderr := runCLI("keygen", "-o", keyPath); code != exitOK {
+		t.Fatalf("keygen: exit %d: %s", code, stderr)
+	}
+	req, err := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "airgap-fp", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := req.Marshal()
+	os.WriteFile(requestPath, raw, 0o600)
+
+	code, stdout, stderr := runCLI("issue", "-signing-key", keyPath, "-request", requestPath, "-expires", "2030-01-01")
+	if code != exitOK {
+		t.Fatalf("issue: exit %d: %s", code, stderr)
+	}
+	data, err := cnwlicense.NewOfflineValidator().VerifyActivationResponse([]byte(stdout), req)
+	if err != nil {
+		t.Fatalf("verify activation response: %v", err)
+	}
+	if !data.ExpiresAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected expiry %s", data.ExpiresAt)
+	}
+
+	if code, _, _ := runCLI("issue", "-signing-key", keyPath, "-request", requestPath, "-key", "CNW-OTHER", "-expires", "1d"); code != exitInvalid {
+		t.Errorf("expected exit %d for a license key mismatch, got %d", exitInvalid, code)
+	}
+}
+
+func TestRun_IssueUsesClock(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock = cnwlicensetest.NewFakeClock(now)
+	t.Cleanup(func() { clock = cnwlicense.SystemClock{} })
+
+	keyPath := filepath.Join(t.TempDir(), "key.json")
+	if code, _, stderr := runCLI("keygen", "-o", keyPath); code != exitOK {
+		t.Fatalf("keygen: exit %d: %s", code, stderr)
+	}
+	code, stdout, stderr := runCLI("issue", "-signing-key", keyPath, "-key", "CNW-TEST-1234", "-expires", "30d")
+	if code != exitOK {
+		t.Fatalf("issue: exit %d: %s", code, stderr)
+	}
+	var file cnwlicense.OfflineLicenseFile
+	var data cnwlicense.OfflineLicenseData
+	if err := json.Unmarshal([]byte(stdout), &file); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(file.License, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !data.IssuedAt.Equal(now) || !data.ExpiresAt.Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("expected issued %s and expiry 30 days later, got %s and %s", now, data.IssuedAt, data.ExpiresAt)
+	}
+}
+
+func TestRun_IssueNullFeaturesFile(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.json")
+	featuresPath := filepath.Join(dir, "features.json")
+	if code, _, stderr := runCLI("keygen", "-o", keyPath); code != exitOK {
+		t.Fatalf("keygen: exit %d: %s", code, stderr)
+	}
+	os.WriteFile(featuresPath, []byte("null"), 0o600)
+
+	code, stdout, stderr := runCLI("issue", "-signing-key", keyPath, "-key", "CNW-TEST-1234", "-expires", "30d",
+		"-features", featuresPath, "-feature", "sso=true")
+	if code != exitOK {
+		t.Fatalf("issue: exit %d: %s", code, stderr)
+	}
+	var file cnwlicense.OfflineLicenseFile
+	var data cnwlicense.OfflineLicenseData
+	if err := json.Unmarshal([]byte(stdout), &file); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(file.License, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !data.Features.Bool("sso", false) {
+		t.Errorf("expected -feature to apply after a null features file, got %v", data.Features)
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"2027-06-30", time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC), true},
+		{"2027-06-30T10:00:00Z", time.Date(2027, 6, 30, 10, 0, 0, 0, time.UTC), true},
+		{"365d", now.AddDate(1, 0, 0), true},
+		{"36h", now.Add(36 * time.Hour), true},
+		{"-1d", time.Time{}, false},
+		{"soon", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiry(tt.in, now)
+		if (err == nil) != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseExpiry(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
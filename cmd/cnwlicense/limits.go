@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+type limitsResult struct {
+	Source        cnwlicense.LicenseSource `json:"source"`
+	MaxCPUPerNode int                      `json:"max_cpu_per_node"` // 0 = unlimited
+	MaxNodes      int                      `json:"max_nodes"`        // 0 = unlimited
+	CPUs          int                      `json:"cpus"`
+	Nodes         int                      `json:"nodes,omitempty"` // as given with -nodes
+	Violations    []string                 `json:"violations,omitempty"`
+}
+
+func runLimits(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("limits", "", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	file := fs.String("file", "", "read limits from this offline license file instead of the server")
+	publicKey := fs.String("public-key", os.Getenv("CNW_LICENSE_PUBLIC_KEY"), "trusted base64 Ed25519 public key for -file (env CNW_LICENSE_PUBLIC_KEY)")
+	nodes := fs.Int("nodes", 0, "current node count to check against max_nodes")
+	var sf serverFlags
+	sf.register(fs)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	res := limitsResult{CPUs: runtime.NumCPU(), Nodes: *nodes}
+	var features cnwlicense.Features
+	if *file != "" {
+		if *publicKey == "" {
+			return fmt.Errorf("%s: %w", *file, errUntrustedKey)
+		}
+		opts := []cnwlicense.OfflineOption{cnwlicense.WithTrustedPublicKey(*publicKey)}
+		if sf.fingerprint != "" {
+			opts = append(opts, cnwlicense.WithMachineFingerprint(sf.fingerprint))
+		}
+		data, err := cnwlicense.NewOfflineValidator(opts...).VerifyFile(*file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *file, err)
+		}
+		res.Source, features = cnwlicense.SourceOffline, data.EffectiveFeatures()
+	} else {
+		client, _, err := sf.client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sf.timeout)
+		defer cancel()
+		resp, err := client.Validate(ctx, cnwlicense.ValidateRequest{LicenseKey: sf.licenseKey})
+		if err != nil {
+			return err
+		}
+		if !resp.Valid {
+			return rejection(resp.Reason)
+		}
+		res.Source, features = cnwlicense.SourceOnline, resp.Features
+	}
+
+	limits := cnwlicense.ExtractHardwareLimits(features)
+	res.MaxCPUPerNode, res.MaxNodes = limits.MaxCPUPerNode, limits.MaxNodes
+	var errs []error
+	if err := cnwlicense.CheckCPU(limits); err != nil {
+		errs = append(errs, err)
+	}
+	if *nodes > 0 {
+		if err := cnwlicense.CheckNodeCount(limits, *nodes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, err := range errs {
+		res.Violations = append(res.Violations, err.Error())
+	}
+
+	if err := emit(stdout, *asJSON, res, func(w io.Writer) {
+		field(w, "Source", res.Source)
+		fmt.Fprintf(w, "CPUs per node:\t%d of %s\n", res.CPUs, limit(res.MaxCPUPerNode))
+		if res.Nodes > 0 {
+			fmt.Fprintf(w, "Nodes:\t%d of %s\n", res.Nodes, limit(res.MaxNodes))
+		} else {
+			fmt.Fprintf(w, "Nodes:\t%s\n", limit(res.MaxNodes))
+		}
+		for _, v := range res.Violations {
+			field(w, "Violation", v)
+		}
+	}); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func limit(n int) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,142 @@
+// Command cnwlicense inspects CNW licenses and machines without writing Go.
+//
+// Usage:
+//
+//	cnwlicense <command> [flags] [args]
+//
+// Commands:
+//
+//	fingerprint        print this machine's fingerprint and its components
+//	verify <file>      verify a signed offline license file and print its contents
+//	validate           validate a license key against the license server
+//	activate           activate this machine against the license server
+//	limits             compare a license's hardware limits with this machine
+//	keygen             generate an Ed25519 signing key file
+//	issue              issue a signed offline license file
+//
+// Every command accepts -json for machine-readable output. Server flags default
+// to the CNW_LICENSE_SERVER, CNW_LICENSE_API_KEY and CNW_LICENSE_KEY environment
+// variables. The exit status identifies the failure (see exitCode).
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// clock is the time source for issued licenses and relative expiries; tests
+// replace it.
+var clock cnwlicense.Clock = cnwlicense.SystemClock{}
+
+// command is a cnwlicense subcommand.
+type command struct {
+	name    string
+	args    string // synopsis of positional arguments
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"fingerprint", "", "print this machine's fingerprint and its components", runFingerprint},
+	{"verify", "<file>", "verify a signed offline license file and print its contents", runVerify},
+	{"validate", "", "validate a license key against the license server", runValidate},
+	{"activate", "", "activate this machine against the license server", runActivate},
+	{"limits", "", "compare a license's hardware limits with this machine", runLimits},
+	{"keygen", "", "generate an Ed25519 signing key file", runKeygen},
+	{"issue", "", "issue a signed offline license file", runIssue},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the process exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], stdout, stderr)
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		}
+		var ue *usageError
+		if !errors.As(err, &ue) || ue.msg != "" {
+			fmt.Fprintf(stderr, "cnwlicense %s: %v\n", cmd.name, err)
+		}
+		return exitCode(err)
+	}
+	fmt.Fprintf(stderr, "cnwlicense: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: cnwlicense <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-20s %s\n", cmd.name+" "+cmd.args, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "cnwlicense <command> -h" for the command's flags.`)
+}
+
+// usageError reports invalid command line arguments. An empty msg means the
+// flag package has already reported the problem.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// newFlagSet returns a flag set for cmd that reports errors to stderr.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: cnwlicense %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before or after positional arguments
+// and returns the positional arguments, checking there are exactly n.
+func parseArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != n {
+		fs.Usage()
+		return nil, &usageError{msg: fmt.Sprintf("expected %d argument(s), got %d", n, len(positional))}
+	}
+	return positional, nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/signer"
+)
+
+// runCLI runs the command line and returns its exit status and output.
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeLicenseFile signs data and writes it as a license file, returning its
+// path and the base64 public key.
+func writeLicenseFile(t *testing.T, data cnwlicense.OfflineLicenseData) (string, string) {
+	t.Helper()
+	s, err := signer.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := s.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(file)
+	path := filepath.Join(t.TempDir(), "license.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, s.PublicKey()
+}
+
+func TestRun_Usage(t *testing.T) {
+	if code, _, _ := runCLI(); code != exitUsage {
+		t.Errorf("expected exit %d without a command, got %d", exitUsage, code)
+	}
+	if code, _, stderr := runCLI("bogus"); code != exitUsage || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("expected usage error for unknown command, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runCLI("verify"); code != exitUsage {
+		t.Errorf("expected usage error for missing file, got %d", code)
+	}
+	if code, _, _ := runCLI("verify", "-h"); code != exitOK {
+		t.Errorf("expected exit 0 for -h, got %d", code)
+	}
+}
+
+func TestRun_Fingerprint(t *testing.T) {
+	t.Setenv("CNW_FINGERPRINT", "fixed-fp")
+	code, stdout, _ := runCLI("fingerprint", "-json")
+	if code != exitOK {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	var res fingerprintResult
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if res.Fingerprint != "fixed-fp" || !res.Override || res.Components == nil || res.Components.Hostname == "" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestRun_Verify(t *testing.T) {
+	data := cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-TEST-1234",
+		Plan:       "enterprise",
+		Features:   cnwlicense.Features{"max_nodes": 10},
+		IssuedAt:   time.Now().Add(-time.Hour),
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+	}
+	path, pub := writeLicenseFile(t, data)
+
+	code, stdout, stderr := runCLI("verify", path, "-public-key", pub)
+	if code != exitOK {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{"CNW-TEST-1234", "enterprise", "max_nodes"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	if code, _, _ := runCLI("verify", "-public-key", pub, "-key", "CNW-OTHER", path); code != exitInvalid {
+		t.Errorf("expected exit %d for a key mismatch, got %d", exitInvalid, code)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	if code, _, _ := runCLI("verify", "-public-key", base64.StdEncoding.EncodeToString(otherPub), path); code != exitSignature {
+		t.Errorf("expected exit %d for an untrusted key, got %d", exitSignature, code)
+	}
+
+	// Without a trusted key, the contents are printed with a warning, and the
+	// command fails only in strict mode.
+	code, stdout, stderr = runCLI("verify", path)
+	if code != exitOK {
+		t.Errorf("expected exit 0 without a trusted key, got %d", code)
+	}
+	if !strings.Contains(stderr, "signature NOT verified against a trusted key") || !strings.Contains(stdout, "CNW-TEST-1234") {
+		t.Errorf("expected warning and contents, got stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if code, _, _ := runCLI("verify", "-require-trusted-key", path); code != exitSignature {
+		t.Errorf("expected exit %d with -require-trusted-key and no key, got %d", exitSignature, code)
+	}
+	if code, _, stderr := runCLI("verify", "-require-trusted-key", "-public-key", pub, path); code != exitOK {
+		t.Errorf("expected exit 0 with -require-trusted-key and a key, got %d: %s", code, stderr)
+	}
+	t.Setenv("CNW_LICENSE_PUBLIC_KEY", pub)
+	if code, _, stderr := runCLI("verify", path); code != exitOK {
+		t.Errorf("expected exit 0 with the key from the environment, got %d: %s", code, stderr)
+	}
+
+	// Expired files still print their contents.
+	data.ExpiresAt = time.Now().Add(-time.Hour)
+	path, pub = writeLicenseFile(t, data)
+	code, stdout, _ = runCLI("verify", "-json", "-public-key", pub, path)
+	if code != exitExpired {
+		t.Fatalf("expected exit %d, got %d", exitExpired, code)
+	}
+	var res struct {
+		LicenseKey string `json:"license_key"`
+		Status     string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if res.LicenseKey != "CNW-TEST-1234" || res.Status != string(cnwlicense.StatusExpired) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+// licenseServer answers every request with status and body.
+func licenseServer(t *testing.T, status int, body any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "api-key" {
+			t.Errorf("expected API key header, got %q", r.Header.Get("X-API-Key"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func serverError(code, message string) map[string]any {
+	return map[string]any{"error": map[string]string{"code": code, "message": message}}
+}
+
+func TestRun_Validate(t *testing.T) {
+	t.Setenv("CNW_LICENSE_API_KEY", "api-key")
+	tests := []struct {
+		name   string
+		status int
+		body   any
+		want   int
+	}{
+		{"valid", http.StatusOK, cnwlicense.ValidateResponse{Valid: true, Plan: "pro"}, exitOK},
+		{"expired", http.StatusOK, cnwlicense.ValidateResponse{Valid: false, Reason: "license expired"}, exitExpired},
+		{"suspended", http.StatusOK, cnwlicense.ValidateResponse{Valid: false, Reason: "license suspended"}, exitInactive},
+		{"rejected", http.StatusOK, cnwlicense.ValidateResponse{Valid: false, Reason: "fingerprint not activated"}, exitInvalid},
+		{"not found", http.StatusNotFound, serverError("NOT_FOUND", "license not found"), exitInvalid},
+		{"unavailable", http.StatusServiceUnavailable, serverError("UNAVAILABLE", "maintenance"), exitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := licenseServer(t, tt.status, tt.body)
+			code, _, stderr := runCLI("validate", "-server", server.URL, "-key", "CNW-KEY", "-fingerprint", "fp")
+			if code != tt.want {
+				t.Errorf("expected exit %d, got %d: %s", tt.want, code, stderr)
+			}
+		})
+	}
+}
+
+func TestRun_Activate(t *testing.T) {
+	t.Setenv("CNW_LICENSE_API_KEY", "api-key")
+	server := licenseServer(t, http.StatusOK, map[string]any{
+		"data": cnwlicense.ActivateResponse{ID: "act-1", Fingerprint: "fp", Hostname: "node-1"},
+	})
+	code, stdout, stderr := runCLI("activate", "-json", "-server", server.URL, "-key", "CNW-KEY", "-fingerprint", "fp")
+	if code != exitOK {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var resp cnwlicense.ActivateResponse
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil || resp.ID != "act-1" {
+		t.Errorf("unexpected output %q: %v", stdout, err)
+	}
+
+	server = licenseServer(t, http.StatusConflict, serverError("ACTIVATION_LIMIT", "activation limit reached"))
+	if code, _, _ := runCLI("activate", "-server", server.URL, "-key", "CNW-KEY", "-fingerprint", "fp"); code != exitInvalid {
+		t.Errorf("expected exit %d, got %d", exitInvalid, code)
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	path, pub := writeLicenseFile(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-TEST-1234",
+		Features:   cnwlicense.Features{"max_nodes": 3},
+		IssuedAt:   time.Now().Add(-time.Hour),
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+	})
+
+	code, stdout, _ := runCLI("limits", "-json", "-file", path, "-public-key", pub, "-nodes", "2")
+	if code != exitOK {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	var res limitsResult
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if res.MaxNodes != 3 || res.MaxCPUPerNode != 0 || res.Source != cnwlicense.SourceOffline {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	code, stdout, _ = runCLI("limits", "-file", path, "-public-key", pub, "-nodes", "5")
+	if code != exitLimits {
+		t.Errorf("expected exit %d, got %d", exitLimits, code)
+	}
+	if !strings.Contains(stdout, "node limit exceeded") {
+		t.Errorf("expected violation in output:\n%s", stdout)
+	}
+
+	if code, _, stderr := runCLI("limits", "-file", path); code != exitSignature || !strings.Contains(stderr, "NOT verified") {
+		t.Errorf("expected exit %d without a trusted key, got %d: %s", exitSignature, code, stderr)
+	}
+}
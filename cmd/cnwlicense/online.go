@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// serverFlags are the flags shared by commands that talk to the license server.
+type serverFlags struct {
+	server      string
+	apiKey      string
+	licenseKey  string
+	fingerprint string
+	timeout     time.Duration
+}
+
+func (f *serverFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.server, "server", os.Getenv("CNW_LICENSE_SERVER"), "license server URL (env CNW_LICENSE_SERVER)")
+	fs.StringVar(&f.apiKey, "api-key", os.Getenv("CNW_LICENSE_API_KEY"), "API key (env CNW_LICENSE_API_KEY)")
+	fs.StringVar(&f.licenseKey, "key", os.Getenv("CNW_LICENSE_KEY"), "license key (env CNW_LICENSE_KEY)")
+	fs.StringVar(&f.fingerprint, "fingerprint", "", "machine fingerprint (default: this machine)")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "overall request timeout")
+}
+
+// client returns a client for the configured server and the fingerprint to send.
+func (f *serverFlags) client() (*cnwlicense.OnlineClient, string, error) {
+	var missing []string
+	if f.server == "" {
+		missing = append(missing, "-server")
+	}
+	if f.apiKey == "" {
+		missing = append(missing, "-api-key")
+	}
+	if f.licenseKey == "" {
+		missing = append(missing, "-key")
+	}
+	if len(missing) > 0 {
+		return nil, "", &usageError{msg: "missing " + strings.Join(missing, ", ")}
+	}
+	fingerprint := f.fingerprint
+	if fingerprint == "" {
+		fp, err := cnwlicense.GenerateFingerprint()
+		if err != nil {
+			return nil, "", err
+		}
+		fingerprint = fp
+	}
+	client := cnwlicense.NewOnlineClient(f.server, f.apiKey,
+		cnwlicense.WithUserAgent("cnwlicense-cli"),
+		cnwlicense.WithFingerprint(fingerprint),
+	)
+	return client, fingerprint, nil
+}
+
+type validateResult struct {
+	*cnwlicense.ValidateResponse
+	LicenseKey  string `json:"license_key"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", "", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	var sf serverFlags
+	sf.register(fs)
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	client, fingerprint, err := sf.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sf.timeout)
+	defer cancel()
+	resp, err := client.Validate(ctx, cnwlicense.ValidateRequest{LicenseKey: sf.licenseKey})
+	if err != nil {
+		return err
+	}
+
+	res := validateResult{ValidateResponse: resp, LicenseKey: sf.licenseKey, Fingerprint: fingerprint}
+	if err := emit(stdout, *asJSON, res, func(w io.Writer) {
+		field(w, "Valid", resp.Valid)
+		field(w, "Reason", resp.Reason)
+		field(w, "License key", sf.licenseKey)
+		field(w, "Fingerprint", fingerprint)
+		field(w, "Plan", resp.Plan)
+		if resp.ExpiresAt != nil {
+			field(w, "Expires", formatTime(*resp.ExpiresAt))
+		}
+		field(w, "Activations left", resp.ActivationRemaining)
+		features(w, "Features", resp.Features)
+	}); err != nil {
+		return err
+	}
+	if !resp.Valid {
+		return rejection(resp.Reason)
+	}
+	return nil
+}
+
+// rejection returns the error for a validation response with valid=false.
+func rejection(reason string) error {
+	sentinel := errLicenseInvalid
+	switch r := strings.ToLower(reason); {
+	case strings.Contains(r, "expired"):
+		sentinel = cnwlicense.ErrLicenseExpired
+	case strings.Contains(r, "suspended"), strings.Contains(r, "revoked"), strings.Contains(r, "inactive"),
+		strings.Contains(r, "not active"):
+		sentinel = cnwlicense.ErrLicenseInactive
+	}
+	if reason == "" {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %s", sentinel, reason)
+}
+
+func runActivate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("activate", "", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	var sf serverFlags
+	sf.register(fs)
+	hostname := fs.String("hostname", "", "hostname to register (default: this machine's)")
+	ip := fs.String("ip", "", "IP address to register")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	client, fingerprint, err := sf.client()
+	if err != nil {
+		return err
+	}
+	if *hostname == "" {
+		if *hostname, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sf.timeout)
+	defer cancel()
+	resp, err := client.Activate(ctx, cnwlicense.ActivateRequest{
+		LicenseKey:  sf.licenseKey,
+		Fingerprint: fingerprint,
+		Hostname:    *hostname,
+		IP:          *ip,
+		OS:          runtime.GOOS,
+	})
+	if err != nil {
+		return err
+	}
+
+	return emit(stdout, *asJSON, resp, func(w io.Writer) {
+		field(w, "Activation", resp.ID)
+		field(w, "License", resp.LicenseID)
+		field(w, "Fingerprint", resp.Fingerprint)
+		field(w, "Hostname", resp.Hostname)
+		field(w, "IP", resp.IP)
+		field(w, "OS", resp.OS)
+		field(w, "Plan", resp.Plan)
+		field(w, "Activated", formatTime(resp.ActivatedAt))
+		features(w, "Features", resp.Features)
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// emit writes v as indented JSON if asJSON is set, and otherwise writes the
+// human-readable form produced by human as aligned columns.
+func emit(w io.Writer, asJSON bool, v any, human func(tw io.Writer)) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	human(tw)
+	return tw.Flush()
+}
+
+// field writes a "name:<tab>value" row, skipping empty values.
+func field(w io.Writer, name string, value any) {
+	s := fmt.Sprint(value)
+	if s == "" || s == "<nil>" {
+		return
+	}
+	fmt.Fprintf(w, "%s:\t%s\n", name, s)
+}
+
+// formatTime formats t for humans, with the time remaining until it.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", t.Format(time.RFC3339), formatRelative(time.Until(t)))
+}
+
+func formatRelative(d time.Duration) string {
+	if d < 0 {
+		return humanDuration(-d) + " ago"
+	}
+	return "in " + humanDuration(d)
+}
+
+// humanDuration rounds d to days, hours or minutes.
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+// features writes one row per feature, sorted by name.
+func features(w io.Writer, name string, f cnwlicense.Features) {
+	if len(f) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "%s:\t\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s\t%v\n", k, f[k])
+	}
+}
+
+func list(values []string) string {
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+type verifyResult struct {
+	*cnwlicense.OfflineLicenseData
+	Status        cnwlicense.LicenseStatus `json:"status"`
+	VerifiedKeyID string                   `json:"verified_key_id,omitempty"`
+	Error         string                   `json:"error,omitempty"`
+}
+
+func runVerify(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("verify", "<file>", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	publicKey := fs.String("public-key", os.Getenv("CNW_LICENSE_PUBLIC_KEY"), "trusted base64 Ed25519 public key (env CNW_LICENSE_PUBLIC_KEY)")
+	requireTrusted := fs.Bool("require-trusted-key", false, "fail (exit 6) unless a trusted public key is given")
+	licenseKey := fs.String("key", "", "expected license key")
+	fingerprint := fs.String("fingerprint", "", "machine fingerprint for node-locked licenses (default: this machine)")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var opts []cnwlicense.OfflineOption
+	if *publicKey != "" {
+		opts = append(opts, cnwlicense.WithTrustedPublicKey(*publicKey))
+	}
+	if *fingerprint != "" {
+		opts = append(opts, cnwlicense.WithMachineFingerprint(*fingerprint))
+	}
+	// Without a trusted key the file is checked against its embedded key only:
+	// its contents can be inspected, with a warning, unless -require-trusted-key
+	// makes that a failure.
+	data, err := cnwlicense.NewOfflineValidator(opts...).VerifyFile(positional[0])
+	if err == nil && *licenseKey != "" && data.LicenseKey != *licenseKey {
+		err = cnwlicense.ErrLicenseKeyMismatch
+	}
+	if *publicKey == "" {
+		fmt.Fprintf(stderr, "WARNING: %s: %v\n", positional[0], errUntrustedKey)
+		if err == nil && *requireTrusted {
+			err = errUntrustedKey
+		}
+	}
+	if data == nil {
+		return err
+	}
+
+	res := verifyResult{OfflineLicenseData: data, Status: data.Status, VerifiedKeyID: data.VerifiedKeyID}
+	if err != nil {
+		res.Status = offlineStatus(err)
+		res.Error = err.Error()
+	}
+	if printErr := emit(stdout, *asJSON, res, func(w io.Writer) {
+		field(w, "Status", res.Status)
+		field(w, "Error", res.Error)
+		field(w, "License key", data.LicenseKey)
+		field(w, "Plan", data.Plan)
+		field(w, "Company", data.CompanyID)
+		field(w, "App", data.AppID)
+		field(w, "Issued", formatTime(data.IssuedAt))
+		if data.NotBefore != nil {
+			field(w, "Not before", formatTime(*data.NotBefore))
+		}
+		field(w, "Expires", formatTime(data.ExpiresAt))
+		if data.GraceRemaining > 0 {
+			field(w, "Grace remaining", humanDuration(data.GraceRemaining))
+		}
+		field(w, "Signing key", res.VerifiedKeyID)
+		field(w, "Fingerprints", list(data.Fingerprints))
+		features(w, "Features", data.Features)
+		features(w, "Degraded features", data.DegradedFeatures)
+	}); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", positional[0], err)
+	}
+	return nil
+}
+
+// offlineStatus returns the license state reported for a verification error.
+func offlineStatus(err error) cnwlicense.LicenseStatus {
+	switch {
+	case errors.Is(err, cnwlicense.ErrLicenseExpired):
+		return cnwlicense.StatusExpired
+	case errors.Is(err, cnwlicense.ErrLicenseRevoked):
+		return cnwlicense.StatusRevoked
+	}
+	return cnwlicense.StatusInvalid
+}
@@ -0,0 +1,207 @@
+package cnwlicense
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CacheStore persists the Manager's last successful validation result so it can
+// be honored while the license server is unreachable. Entries are opaque,
+// integrity-protected blobs produced by the Manager.
+type CacheStore interface {
+	// Load returns the cached entry for licenseKey, or (nil, nil) if there is none.
+	Load(licenseKey string) ([]byte, error)
+	// Save stores the entry for licenseKey, replacing any previous one.
+	Save(licenseKey string, data []byte) error
+	// Delete removes the entry for licenseKey. Deleting a missing entry is not an error.
+	Delete(licenseKey string) error
+}
+
+// FileCacheStore is a CacheStore that keeps one file per license key in a directory.
+type FileCacheStore struct {
+	dir string
+}
+
+// NewFileCacheStore creates a file-based cache store rooted at dir.
+// The directory is created on first save if it does not exist.
+func NewFileCacheStore(dir string) *FileCacheStore {
+	return &FileCacheStore{dir: dir}
+}
+
+// Load reads the cache file for licenseKey.
+func (s *FileCacheStore) Load(licenseKey string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(licenseKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache file: %w", err)
+	}
+	return data, nil
+}
+
+// Save atomically writes the cache file for licenseKey with 0600 permissions.
+func (s *FileCacheStore) Save(licenseKey string, data []byte) error {
+	return writeFileAtomic(s.path(licenseKey), data)
+}
+
+// Delete removes the cache file for licenseKey.
+func (s *FileCacheStore) Delete(licenseKey string) error {
+	if err := os.Remove(s.path(licenseKey)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove cache file: %w", err)
+	}
+	return nil
+}
+
+// path returns the cache file path. The license key is hashed so it does not
+// appear in file names.
+func (s *FileCacheStore) path(licenseKey string) string {
+	sum := sha256.Sum256([]byte(licenseKey))
+	return filepath.Join(s.dir, fmt.Sprintf("license-%x.json", sum[:8]))
+}
+
+// writeFileAtomic writes data to a temp file in the target directory and renames
+// it into place, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
+
+// cacheEntry is the payload persisted after a successful online validation.
+type cacheEntry struct {
+	LicenseKey  string           `json:"license_key"`
+	Fingerprint string           `json:"fingerprint"`
+	Response    ValidateResponse `json:"response"`
+	ValidatedAt time.Time        `json:"validated_at"`
+}
+
+// WithLicenseCache makes the Manager persist the last successful online validation
+// to store and keep honoring it for grace after it was obtained, whenever the
+// license server cannot be reached. The cache is consulted before the offline
+// fallback (see WithOfflineFallback). Explicit rejections from the server
+// (not found, inactive, expired) delete the cached entry. The cache is only
+// used together with WithCacheSecret.
+func WithLicenseCache(store CacheStore, grace time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.cache = store
+		m.cacheGrace = grace
+	}
+}
+
+// WithCacheSecret sets the HMAC key protecting cache entries against editing.
+// It is required for WithLicenseCache: a key derivable on the end user's
+// machine would let anyone re-seal an edited entry, so without a secret no
+// entries are saved or served.
+func WithCacheSecret(secret []byte) ManagerOption {
+	return func(m *Manager) {
+		m.cacheSecret = secret
+	}
+}
+
+// saveCache stores a successful validation result. Failures are ignored: the
+// cache is a best-effort safety net and must never fail a successful validation.
+func (m *Manager) saveCache(licenseKey, fingerprint string, resp *ValidateResponse) {
+	if m.cache == nil || len(m.cacheSecret) == 0 {
+		return
+	}
+	raw, err := sealJSON(m.cacheSecret, cacheEntry{
+		LicenseKey:  licenseKey,
+		Fingerprint: fingerprint,
+		Response:    *resp,
+		ValidatedAt: m.clock.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+	_ = m.cache.Save(licenseKey, raw)
+}
+
+// loadCache reads and verifies the cached entry for licenseKey on this machine.
+func (m *Manager) loadCache(licenseKey, fingerprint string) (*cacheEntry, error) {
+	if len(m.cacheSecret) == 0 {
+		return nil, fmt.Errorf("%w: no cache secret configured (see WithCacheSecret)", ErrCacheMiss)
+	}
+	raw, err := m.cache.Load(licenseKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, ErrCacheMiss
+	}
+
+	var entry cacheEntry
+	if err := openJSON(m.cacheSecret, raw, &entry, ErrCacheTampered); err != nil {
+		return nil, err
+	}
+	if entry.LicenseKey != licenseKey || entry.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("%w: entry belongs to a different license or machine", ErrCacheTampered)
+	}
+	return &entry, nil
+}
+
+// dropCache removes the cached entry after an explicit rejection.
+func (m *Manager) dropCache(licenseKey string) {
+	if m.cache != nil {
+		_ = m.cache.Delete(licenseKey)
+	}
+}
+
+// validateCached serves a result from the last-known-good cache, provided the
+// entry is intact, belongs to this license key and machine, was not validated in
+// the future (a clock set back), is within the grace period and the license
+// itself has not expired (or is within the expiry grace period, see
+// WithManagerExpiryGrace).
+func (m *Manager) validateCached(licenseKey, fingerprint string) (*LicenseInfo, error) {
+	entry, err := m.loadCache(licenseKey, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.clock.Now()
+	if entry.ValidatedAt.After(now) {
+		return nil, fmt.Errorf("%w: validated at %s, after the current time", ErrCacheTampered, entry.ValidatedAt.Format(time.RFC3339))
+	}
+	graceEndsAt := entry.ValidatedAt.Add(m.cacheGrace)
+	if !now.Before(graceEndsAt) {
+		return nil, fmt.Errorf("%w: ended at %s", ErrCacheGraceExpired, graceEndsAt.Format(time.RFC3339))
+	}
+	info := m.responseInfo(licenseKey, fingerprint, &entry.Response, SourceCache)
+	if info == nil {
+		return nil, ErrLicenseExpired
+	}
+
+	if err := CheckCPU(ExtractHardwareLimits(info.Features)); err != nil {
+		return nil, err
+	}
+
+	validatedAt := entry.ValidatedAt
+	info.ValidatedAt = &validatedAt
+	info.GraceEndsAt = &graceEndsAt
+	return info, nil
+}
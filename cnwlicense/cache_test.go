@@ -0,0 +1,309 @@
+package cnwlicense
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyLicenseServer returns a server that answers validate requests with resp
+// until down is set, after which it responds 503.
+func flakyLicenseServer(t *testing.T, resp ValidateResponse, down *atomic.Bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestManager_Cache_ServesLastKnownGood(t *testing.T) {
+	expires := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	var down atomic.Bool
+	server := flakyLicenseServer(t, ValidateResponse{
+		Valid:     true,
+		Plan:      "enterprise",
+		ExpiresAt: &expires,
+		Features:  map[string]interface{}{"max_nodes": float64(5)},
+	}, &down)
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+
+	info, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("online validate: unexpected error: %v", err)
+	}
+	if info.Source != SourceOnline {
+		t.Errorf("expected source online, got %s", info.Source)
+	}
+
+	down.Store(true)
+	before := time.Now()
+	info, err = mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("cached validate: unexpected error: %v", err)
+	}
+	if info.Source != SourceCache {
+		t.Errorf("expected source cache, got %s", info.Source)
+	}
+	if !info.Valid || info.Plan != "enterprise" {
+		t.Errorf("expected cached plan enterprise, got valid=%v plan=%s", info.Valid, info.Plan)
+	}
+	if info.ExpiresAt == nil || !info.ExpiresAt.Equal(expires) {
+		t.Errorf("expected cached expires_at %v, got %v", expires, info.ExpiresAt)
+	}
+	if info.ValidatedAt == nil || info.GraceEndsAt == nil {
+		t.Fatal("expected ValidatedAt and GraceEndsAt on cached result")
+	}
+	if d := info.GraceEndsAt.Sub(before); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expected grace to end ~1h from now, got %v", d)
+	}
+}
+
+func TestManager_Cache_TamperedEntryRejected(t *testing.T) {
+	var down atomic.Bool
+	server := flakyLicenseServer(t, ValidateResponse{Valid: true, Plan: "basic"}, &down)
+
+	dir := t.TempDir()
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(NewFileCacheStore(dir), time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+	if _, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Upgrade the plan by editing the cache file.
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 cache file, got %d", len(files))
+	}
+	raw, _ := os.ReadFile(files[0])
+	os.WriteFile(files[0], []byte(strings.Replace(string(raw), "basic", "enterprise", 1)), 0600)
+
+	down.Store(true)
+	_, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if !errors.Is(err, ErrCacheTampered) {
+		t.Errorf("expected ErrCacheTampered, got %v", err)
+	}
+}
+
+func TestManager_Cache_GraceExpired(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server := flakyLicenseServer(t, ValidateResponse{}, &down)
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+
+	raw, _ := sealJSON([]byte("app-secret"), cacheEntry{
+		LicenseKey:  "CNW-TEST-1234",
+		Fingerprint: "node-fp",
+		Response:    ValidateResponse{Valid: true},
+		ValidatedAt: time.Now().Add(-2 * time.Hour),
+	})
+	store.Save("CNW-TEST-1234", raw)
+
+	_, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if !errors.Is(err, ErrCacheGraceExpired) {
+		t.Errorf("expected ErrCacheGraceExpired, got %v", err)
+	}
+}
+
+func TestManager_Cache_FutureValidatedAtRejected(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server := flakyLicenseServer(t, ValidateResponse{}, &down)
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+
+	// An entry validated "later" than now means the clock was set back.
+	raw, _ := sealJSON([]byte("app-secret"), cacheEntry{
+		LicenseKey:  "CNW-TEST-1234",
+		Fingerprint: "node-fp",
+		Response:    ValidateResponse{Valid: true},
+		ValidatedAt: time.Now().Add(30 * 24 * time.Hour),
+	})
+	store.Save("CNW-TEST-1234", raw)
+
+	_, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if !errors.Is(err, ErrCacheTampered) {
+		t.Errorf("expected ErrCacheTampered, got %v", err)
+	}
+}
+
+func TestManager_Cache_RequiresSecret(t *testing.T) {
+	var down atomic.Bool
+	server := flakyLicenseServer(t, ValidateResponse{Valid: true}, &down)
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+	)
+	if _, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw, _ := store.Load("CNW-TEST-1234"); raw != nil {
+		t.Error("expected no cache entry without a cache secret")
+	}
+
+	// Entries sealed with a key anyone can compute are not trusted either.
+	raw, _ := sealJSON(deriveKey("cache", "CNW-TEST-1234", "node-fp"), cacheEntry{
+		LicenseKey:  "CNW-TEST-1234",
+		Fingerprint: "node-fp",
+		Response:    ValidateResponse{Valid: true},
+		ValidatedAt: time.Now(),
+	})
+	store.Save("CNW-TEST-1234", raw)
+	down.Store(true)
+	if _, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss without a cache secret, got %v", err)
+	}
+}
+
+func TestManager_Cache_ExpiredLicenseNotServed(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server := flakyLicenseServer(t, ValidateResponse{}, &down)
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, 24*time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+
+	expired := time.Now().Add(-time.Minute)
+	raw, _ := sealJSON([]byte("app-secret"), cacheEntry{
+		LicenseKey:  "CNW-TEST-1234",
+		Fingerprint: "node-fp",
+		Response:    ValidateResponse{Valid: true, ExpiresAt: &expired},
+		ValidatedAt: time.Now().Add(-time.Hour),
+	})
+	store.Save("CNW-TEST-1234", raw)
+
+	_, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if !errors.Is(err, ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired, got %v", err)
+	}
+}
+
+func TestManager_Cache_BoundToSecretAndMachine(t *testing.T) {
+	var down atomic.Bool
+	server := flakyLicenseServer(t, ValidateResponse{Valid: true}, &down)
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+	if _, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	down.Store(true)
+
+	// Same store, different secret: entry cannot be verified.
+	other := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("other-secret")),
+	)
+	if _, err := other.ValidateAndEnforce(context.Background(), "CNW-TEST-1234"); !errors.Is(err, ErrCacheTampered) {
+		t.Errorf("expected ErrCacheTampered with wrong secret, got %v", err)
+	}
+
+	// Same secret, different machine: entry is rejected.
+	copied := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("other-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+	if _, err := copied.ValidateAndEnforce(context.Background(), "CNW-TEST-1234"); !errors.Is(err, ErrCacheTampered) {
+		t.Errorf("expected ErrCacheTampered on another machine, got %v", err)
+	}
+}
+
+func TestManager_Cache_DroppedOnRejection(t *testing.T) {
+	var rejected atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if rejected.Load() {
+			json.NewEncoder(w).Encode(ValidateResponse{Valid: false, Reason: "license is suspended"})
+			return
+		}
+		json.NewEncoder(w).Encode(ValidateResponse{Valid: true})
+	}))
+	defer server.Close()
+
+	store := NewFileCacheStore(t.TempDir())
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithLicenseCache(store, time.Hour),
+		WithCacheSecret([]byte("app-secret")),
+	)
+	mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if raw, _ := store.Load("CNW-TEST-1234"); raw == nil {
+		t.Fatal("expected cache entry after successful validation")
+	}
+
+	rejected.Store(true)
+	mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if raw, _ := store.Load("CNW-TEST-1234"); raw != nil {
+		t.Error("expected cache entry to be deleted after rejection")
+	}
+}
+
+func TestFileCacheStore(t *testing.T) {
+	store := NewFileCacheStore(filepath.Join(t.TempDir(), "nested"))
+
+	raw, err := store.Load("CNW-TEST-1234")
+	if err != nil || raw != nil {
+		t.Fatalf("expected empty load, got %q, %v", raw, err)
+	}
+	if err := store.Save("CNW-TEST-1234", []byte("entry")); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	raw, err = store.Load("CNW-TEST-1234")
+	if err != nil || string(raw) != "entry" {
+		t.Fatalf("expected saved entry, got %q, %v", raw, err)
+	}
+	if info, err := os.Stat(store.path("CNW-TEST-1234")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected 0600 cache file, got %v, %v", info.Mode().Perm(), err)
+	}
+	if err := store.Delete("CNW-TEST-1234"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.Delete("CNW-TEST-1234"); err != nil {
+		t.Fatalf("delete missing: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,8 +13,9 @@ import (
 )
 
 const (
-	defaultTimeout   = 10 * time.Second
-	maxResponseBytes = 1 << 20 // 1 MB
+	defaultTimeout        = 10 * time.Second
+	defaultRateLimitDelay = time.Second // used when a 429 carries no Retry-After
+	maxResponseBytes      = 1 << 20     // 1 MB
 )
 
 // OnlineClient communicates with the CNW License Server HTTP API.
@@ -25,6 +27,13 @@ type OnlineClient struct {
 	userAgent   string
 	fingerprint string
 	metadata    map[string]interface{}
+	retry       RetryPolicy
+	clock       Clock
+
+	rateLimitRetries int           // automatic retries of 429 responses
+	rateLimitMaxWait time.Duration // longest Retry-After honored automatically (0 = no cap)
+
+	sleep func(ctx context.Context, d time.Duration) error // waits between attempts; replaced in tests
 }
 
 // NewOnlineClient creates a new client for the CNW License Server.
@@ -36,6 +45,8 @@ func NewOnlineClient(serverURL, apiKey string, opts ...ClientOption) *OnlineClie
 		apiKey:    apiKey,
 		timeout:   defaultTimeout,
 		userAgent: "cnw-license-sdk-go/1.0",
+		clock:     SystemClock{},
+		sleep:     sleepContext,
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -93,14 +104,91 @@ func (c *OnlineClient) Activate(ctx context.Context, req ActivateRequest) (*Acti
 	return &wrapper.Data, nil
 }
 
+// Deactivate releases a machine activation so its slot can be reused.
+// The activation is identified by req.ActivationID, or by req.LicenseKey and
+// req.Fingerprint. If neither ActivationID nor Fingerprint is set and a client-level
+// fingerprint is set via WithFingerprint, it is automatically used.
+// The server wraps the response in {data: ...}.
+func (c *OnlineClient) Deactivate(ctx context.Context, req DeactivateRequest) (*DeactivateResponse, error) {
+	if req.ActivationID == "" && req.Fingerprint == "" && c.fingerprint != "" {
+		req.Fingerprint = c.fingerprint
+	}
+	if req.ActivationID == "" && (req.LicenseKey == "" || req.Fingerprint == "") {
+		return nil, fmt.Errorf("deactivate: activation ID or license key and fingerprint are required")
+	}
+	var wrapper struct {
+		Data DeactivateResponse `json:"data"`
+	}
+	if err := c.doJSON(ctx, "/v1/deactivate", req, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
+// Heartbeat checks in an activation with the server, updating its LastSeenAt.
+// The activation is identified the same way as in Deactivate.
+// The server wraps the response in {data: ...}.
+func (c *OnlineClient) Heartbeat(ctx context.Context, req HeartbeatRequest) (*HeartbeatResponse, error) {
+	if req.ActivationID == "" && req.Fingerprint == "" && c.fingerprint != "" {
+		req.Fingerprint = c.fingerprint
+	}
+	if req.ActivationID == "" && (req.LicenseKey == "" || req.Fingerprint == "") {
+		return nil, fmt.Errorf("heartbeat: activation ID or license key and fingerprint are required")
+	}
+	var wrapper struct {
+		Data HeartbeatResponse `json:"data"`
+	}
+	if err := c.doJSON(ctx, "/v1/heartbeat", req, &wrapper); err != nil {
+		return nil, err
+	}
+	return &wrapper.Data, nil
+}
+
 // doJSON performs a POST request with JSON body and decodes the response into dest.
 // On non-2xx responses, it parses the server error format and returns a mapped error.
+// Transient failures are retried according to the client's RetryPolicy, and 429
+// responses according to WithRateLimitRetry, as long as the context allows it.
 func (c *OnlineClient) doJSON(ctx context.Context, path string, body, dest interface{}) error {
 	payload, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
+	attempts := c.retry.attempts()
+	var retries, rateLimitRetries int
+	for {
+		err = c.doOnce(ctx, path, payload, dest)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		var delay time.Duration
+		switch {
+		case errors.Is(err, ErrRateLimited) && rateLimitRetries < c.rateLimitRetries:
+			rateLimitRetries++
+			delay = defaultRateLimitDelay
+			var se *ServerError
+			if errors.As(err, &se) && se.RetryAfter > 0 {
+				delay = se.RetryAfter
+			}
+			if c.rateLimitMaxWait > 0 && delay > c.rateLimitMaxWait {
+				return err
+			}
+		case retries+1 < attempts && c.retry.retryable(err):
+			retries++
+			delay = c.retry.backoff(retries)
+		default:
+			return err
+		}
+
+		if c.sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// doOnce performs a single POST attempt with the already-marshaled payload.
+func (c *OnlineClient) doOnce(ctx context.Context, path string, payload []byte, dest interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+path, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
@@ -123,7 +211,7 @@ func (c *OnlineClient) doJSON(ctx context.Context, path string, body, dest inter
 	}
 
 	if resp.StatusCode >= 400 {
-		return c.parseError(resp.StatusCode, respBody)
+		return c.parseError(resp.StatusCode, resp.Header, respBody)
 	}
 
 	if err := json.Unmarshal(respBody, dest); err != nil {
@@ -134,7 +222,10 @@ func (c *OnlineClient) doJSON(ctx context.Context, path string, body, dest inter
 
 // parseError parses the server error response format:
 // {"error": {"code": "...", "message": "..."}}
-func (c *OnlineClient) parseError(statusCode int, body []byte) error {
+// The Retry-After header, if present, is attached to the resulting ServerError.
+func (c *OnlineClient) parseError(statusCode int, header http.Header, body []byte) error {
+	retryAfter := parseRetryAfter(header.Get("Retry-After"), c.clock.Now())
+
 	var errResp struct {
 		Error struct {
 			Code    string `json:"code"`
@@ -142,16 +233,18 @@ func (c *OnlineClient) parseError(statusCode int, body []byte) error {
 		} `json:"error"`
 	}
 	if err := json.Unmarshal(body, &errResp); err != nil {
-		return &ServerError{
+		return mapServerError(&ServerError{
 			StatusCode: statusCode,
 			Code:       "UNKNOWN",
 			Message:    string(body),
-		}
+			RetryAfter: retryAfter,
+		})
 	}
 	se := &ServerError{
 		StatusCode: statusCode,
 		Code:       errResp.Error.Code,
 		Message:    errResp.Error.Message,
+		RetryAfter: retryAfter,
 	}
 	return mapServerError(se)
 }
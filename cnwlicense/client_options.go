@@ -53,3 +53,33 @@ func WithMetadata(m map[string]string) ClientOption {
 		o.metadata = md
 	}
 }
+
+// WithRetry enables automatic retries of transient failures (network errors and
+// retryable 5xx responses) using exponential backoff with jitter.
+// Retries stop early if the context is canceled or its deadline would be exceeded.
+// Use DefaultRetryPolicy() for sensible defaults.
+func WithRetry(p RetryPolicy) ClientOption {
+	return func(o *OnlineClient) {
+		o.retry = p
+	}
+}
+
+// WithClientClock sets the Clock used to resolve HTTP-date Retry-After headers
+// (default: the system clock).
+func WithClientClock(c Clock) ClientOption {
+	return func(o *OnlineClient) {
+		o.clock = c
+	}
+}
+
+// WithRateLimitRetry makes the client wait and retry automatically when the server
+// responds with 429 (ErrRateLimited), up to maxRetries times. The delay is taken
+// from the Retry-After header (1s if absent). If the requested delay exceeds maxWait
+// (0 = no cap) or the context deadline, the ErrRateLimited error is returned instead.
+// Rate-limit retries are counted separately from WithRetry attempts.
+func WithRateLimitRetry(maxRetries int, maxWait time.Duration) ClientOption {
+	return func(o *OnlineClient) {
+		o.rateLimitRetries = maxRetries
+		o.rateLimitMaxWait = maxWait
+	}
+}
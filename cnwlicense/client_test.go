@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -467,3 +468,236 @@ func TestOnlineClient_ClientLevelMetadata(t *testing.T) {
 		t.Fatalf("override validate: unexpected error: %v", err)
 	}
 }
+
+func TestOnlineClient_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{
+				"code":    "RATE_LIMITED",
+				"message": "too many requests",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key")
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	var se *ServerError
+	if !errors.As(err, &se) {
+		t.Fatal("expected errors.As to return ServerError")
+	}
+	if se.RetryAfter != 30*time.Second {
+		t.Errorf("expected RetryAfter 30s, got %v", se.RetryAfter)
+	}
+}
+
+// testClock is a settable Clock for tests in this package.
+type testClock struct {
+	now atomic.Pointer[time.Time]
+}
+
+func newTestClock(t time.Time) *testClock {
+	c := &testClock{}
+	c.now.Store(&t)
+	return c
+}
+
+func (c *testClock) Now() time.Time { return *c.now.Load() }
+
+func (c *testClock) Advance(d time.Duration) {
+	t := c.Now().Add(d)
+	c.now.Store(&t)
+}
+
+func TestOnlineClient_RateLimited_StatusOnly(t *testing.T) {
+	// A 429 from a proxy carries no JSON body but must still map to ErrRateLimited.
+	clock := newTestClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	retryAt := clock.Now().Add(2 * time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", retryAt.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithClientClock(clock))
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	var se *ServerError
+	if !errors.As(err, &se) {
+		t.Fatal("expected errors.As to return ServerError")
+	}
+	if se.RetryAfter != 2*time.Minute {
+		t.Errorf("expected RetryAfter 2m from HTTP-date, got %v", se.RetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "120", 2 * time.Minute},
+		{"negative seconds", "-5", 0},
+		{"http date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"http date in past", now.Add(-time.Hour).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlineClient_Deactivate_ByActivationID(t *testing.T) {
+	deactivatedAt := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/deactivate" {
+			t.Errorf("expected /v1/deactivate, got %s", r.URL.Path)
+		}
+
+		var req DeactivateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ActivationID != "act-001" {
+			t.Errorf("expected activation_id act-001, got %s", req.ActivationID)
+		}
+		if req.Fingerprint != "" {
+			t.Errorf("expected no fingerprint when deactivating by ID, got %s", req.Fingerprint)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": DeactivateResponse{
+				ID:                  "act-001",
+				Fingerprint:         "abc123",
+				DeactivatedAt:       deactivatedAt,
+				ActivationRemaining: 4,
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithFingerprint("client-fp"))
+	resp, err := client.Deactivate(context.Background(), DeactivateRequest{ActivationID: "act-001"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ID != "act-001" {
+		t.Errorf("expected ID act-001, got %s", resp.ID)
+	}
+	if resp.ActivationRemaining != 4 {
+		t.Errorf("expected 4 remaining, got %d", resp.ActivationRemaining)
+	}
+	if !resp.DeactivatedAt.Equal(deactivatedAt) {
+		t.Errorf("expected deactivated_at %v, got %v", deactivatedAt, resp.DeactivatedAt)
+	}
+}
+
+func TestOnlineClient_Deactivate_ByLicenseKeyUsesClientFingerprint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req DeactivateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.LicenseKey != "CNW-TEST-1234" {
+			t.Errorf("expected license key CNW-TEST-1234, got %s", req.LicenseKey)
+		}
+		if req.Fingerprint != "client-fp" {
+			t.Errorf("expected client-level fingerprint, got %s", req.Fingerprint)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": DeactivateResponse{ID: "act-002", Fingerprint: req.Fingerprint},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithFingerprint("client-fp"))
+	resp, err := client.Deactivate(context.Background(), DeactivateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Fingerprint != "client-fp" {
+		t.Errorf("expected fingerprint client-fp, got %s", resp.Fingerprint)
+	}
+}
+
+func TestOnlineClient_Deactivate_MissingIdentity(t *testing.T) {
+	client := NewOnlineClient("http://127.0.0.1:0", "test-key")
+	_, err := client.Deactivate(context.Background(), DeactivateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err == nil {
+		t.Fatal("expected error when neither activation ID nor fingerprint is set")
+	}
+}
+
+func TestOnlineClient_Deactivate_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{
+				"code":    "ACTIVATION_NOT_FOUND",
+				"message": "activation not found",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key")
+	_, err := client.Deactivate(context.Background(), DeactivateRequest{ActivationID: "act-missing"})
+	if !errors.Is(err, ErrActivationNotFound) {
+		t.Errorf("expected ErrActivationNotFound, got %v", err)
+	}
+}
+
+func TestOnlineClient_Heartbeat(t *testing.T) {
+	lastSeen := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/heartbeat" {
+			t.Errorf("expected /v1/heartbeat, got %s", r.URL.Path)
+		}
+		var req HeartbeatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.LicenseKey != "CNW-TEST-1234" || req.Fingerprint != "abc123" {
+			t.Errorf("unexpected heartbeat request: %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": HeartbeatResponse{ID: "act-001", Fingerprint: req.Fingerprint, LastSeenAt: lastSeen},
+		})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key")
+	resp, err := client.Heartbeat(context.Background(), HeartbeatRequest{
+		LicenseKey:  "CNW-TEST-1234",
+		Fingerprint: "abc123",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.LastSeenAt.Equal(lastSeen) {
+		t.Errorf("expected last_seen_at %v, got %v", lastSeen, resp.LastSeenAt)
+	}
+}
+
+func TestOnlineClient_Heartbeat_MissingIdentity(t *testing.T) {
+	client := NewOnlineClient("http://127.0.0.1:0", "test-key")
+	if _, err := client.Heartbeat(context.Background(), HeartbeatRequest{}); err == nil {
+		t.Fatal("expected error when activation is not identified")
+	}
+}
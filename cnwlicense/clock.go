@@ -0,0 +1,16 @@
+package cnwlicense
+
+import "time"
+
+// Clock provides the current time for expiry, grace period and key validity
+// checks. Inject a fake implementation (e.g. cnwlicensetest.FakeClock) to test
+// time-dependent behavior without real timestamps or sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the default Clock, backed by time.Now.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time { return time.Now() }
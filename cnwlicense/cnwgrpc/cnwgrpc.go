@@ -0,0 +1,161 @@
+// Package cnwgrpc provides gRPC server interceptors that enforce licenses and
+// licensed features using a cnwlicense.Manager.
+//
+//	srv := grpc.NewServer(
+//	    grpc.ChainUnaryInterceptor(cnwgrpc.UnaryServerInterceptor(mgr,
+//	        cnwgrpc.WithMethodFeatures(map[string][]string{
+//	            "/reports.v1.Reports/Export": {"advanced_reports"},
+//	        }),
+//	    )),
+//	    grpc.ChainStreamInterceptor(cnwgrpc.StreamServerInterceptor(mgr)),
+//	)
+//
+// Like the HTTP middleware, the interceptors check the Manager's latest
+// validation result (see cnwlicense.Manager.Authorize) and never call the
+// license server per request.
+//
+// cnwgrpc is a separate module so that the core SDK does not depend on gRPC.
+package cnwgrpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the errdetails.ErrorInfo attached to denials.
+const ErrorDomain = "cnwlicense"
+
+// Option configures the interceptors.
+type Option func(*config)
+
+type config struct {
+	features       []string            // required on every method
+	methodFeatures map[string][]string // by full method name or "/pkg.Service/*"
+	skip           map[string]bool
+}
+
+// WithRequiredFeatures requires the listed features on every method.
+func WithRequiredFeatures(features ...string) Option {
+	return func(c *config) {
+		c.features = append(c.features, features...)
+	}
+}
+
+// WithMethodFeatures sets the features required per method. Keys are full method
+// names ("/pkg.Service/Method") or "/pkg.Service/*" for every method of a service;
+// both apply if present. Features set with WithRequiredFeatures are always required.
+func WithMethodFeatures(features map[string][]string) Option {
+	return func(c *config) {
+		for method, fs := range features {
+			c.methodFeatures[method] = append(c.methodFeatures[method], fs...)
+		}
+	}
+}
+
+// WithSkipMethods exempts the listed full method names (e.g. health checks)
+// from license enforcement.
+func WithSkipMethods(methods ...string) Option {
+	return func(c *config) {
+		for _, m := range methods {
+			c.skip[m] = true
+		}
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		methodFeatures: make(map[string][]string),
+		skip:           make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// requiredFeatures returns the features required for fullMethod.
+func (c *config) requiredFeatures(fullMethod string) []string {
+	features := append([]string(nil), c.features...)
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		features = append(features, c.methodFeatures[fullMethod[:i+1]+"*"]...)
+	}
+	return append(features, c.methodFeatures[fullMethod]...)
+}
+
+// authorize checks the license for fullMethod and returns the context to use
+// for the call.
+func (c *config) authorize(ctx context.Context, mgr *cnwlicense.Manager, fullMethod string) (context.Context, error) {
+	if c.skip[fullMethod] {
+		return ctx, nil
+	}
+	info, err := mgr.Authorize(c.requiredFeatures(fullMethod)...)
+	if err != nil {
+		return ctx, Status(err).Err()
+	}
+	return cnwlicense.ContextWithLicense(ctx, info), nil
+}
+
+// UnaryServerInterceptor returns a unary interceptor that rejects calls unless
+// the license is usable and the method's required features are licensed.
+// Admitted calls carry the LicenseInfo in their context (see
+// cnwlicense.LicenseFromContext).
+func UnaryServerInterceptor(mgr *cnwlicense.Manager, opts ...Option) grpc.UnaryServerInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := c.authorize(ctx, mgr, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that rejects streams unless
+// the license is usable and the method's required features are licensed. The
+// check is made once, when the stream is opened.
+func StreamServerInterceptor(mgr *cnwlicense.Manager, opts ...Option) grpc.StreamServerInterceptor {
+	c := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := c.authorize(ss.Context(), mgr, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &licensedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// licensedStream overrides the context of a ServerStream.
+type licensedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *licensedStream) Context() context.Context { return s.ctx }
+
+// Status converts an error from cnwlicense.Manager.Authorize into a gRPC status:
+// codes.PermissionDenied for ErrFeatureNotLicensed and codes.FailedPrecondition
+// for an unusable license (ErrNoLicense, ErrLicenseExpired, ErrLicenseInactive).
+// The status carries an errdetails.ErrorInfo whose Reason is
+// cnwlicense.DenialCode(err) and whose Domain is ErrorDomain.
+func Status(err error) *status.Status {
+	code := codes.FailedPrecondition
+	if errors.Is(err, cnwlicense.ErrFeatureNotLicensed) {
+		code = codes.PermissionDenied
+	}
+	st := status.New(code, err.Error())
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: cnwlicense.DenialCode(err),
+		Domain: ErrorDomain,
+	})
+	if detailErr != nil {
+		return st
+	}
+	return detailed
+}
@@ -0,0 +1,180 @@
+package cnwgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	checkMethod = "/grpc.health.v1.Health/Check"
+	watchMethod = "/grpc.health.v1.Health/Watch"
+)
+
+// healthServer fails with codes.Internal unless the call context carries the
+// LicenseInfo set by the interceptors.
+type healthServer struct {
+	healthgrpc.UnimplementedHealthServer
+}
+
+func (healthServer) Check(ctx context.Context, _ *healthgrpc.HealthCheckRequest) (*healthgrpc.HealthCheckResponse, error) {
+	if _, ok := cnwlicense.LicenseFromContext(ctx); !ok {
+		return nil, status.Error(codes.Internal, "no license in context")
+	}
+	return &healthgrpc.HealthCheckResponse{Status: healthgrpc.HealthCheckResponse_SERVING}, nil
+}
+
+func (healthServer) Watch(_ *healthgrpc.HealthCheckRequest, stream healthgrpc.Health_WatchServer) error {
+	if _, ok := cnwlicense.LicenseFromContext(stream.Context()); !ok {
+		return status.Error(codes.Internal, "no license in context")
+	}
+	return stream.Send(&healthgrpc.HealthCheckResponse{Status: healthgrpc.HealthCheckResponse_SERVING})
+}
+
+// newManager returns a Manager validated against a license server that answers
+// with resp.
+func newManager(t *testing.T, resp *cnwlicense.ValidateResponse) *cnwlicense.Manager {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(
+		cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	mgr.ValidateAndEnforce(context.Background(), "test-key")
+	return mgr
+}
+
+// dial serves the health service through the interceptors over bufconn.
+func dial(t *testing.T, mgr *cnwlicense.Manager, opts ...Option) healthgrpc.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(mgr, opts...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(mgr, opts...)),
+	)
+	healthgrpc.RegisterHealthServer(srv, healthServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthgrpc.NewHealthClient(conn)
+}
+
+func check(client healthgrpc.HealthClient) error {
+	_, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{})
+	return err
+}
+
+func watch(client healthgrpc.HealthClient) error {
+	stream, err := client.Watch(context.Background(), &healthgrpc.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	_, err = stream.Recv()
+	return err
+}
+
+// assertDenied checks err's code and the reason in its ErrorInfo detail.
+func assertDenied(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		t.Fatalf("expected %s, got %v", code, err)
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			if info.Reason != reason || info.Domain != ErrorDomain {
+				t.Errorf("expected reason %s in domain %s, got %s in %s", reason, ErrorDomain, info.Reason, info.Domain)
+			}
+			return
+		}
+	}
+	t.Errorf("expected ErrorInfo detail, got %v", st.Details())
+}
+
+func TestInterceptors_MethodFeatures(t *testing.T) {
+	mgr := newManager(t, &cnwlicense.ValidateResponse{
+		Valid:    true,
+		Plan:     "enterprise",
+		Features: cnwlicense.Features{"health": true, "streaming": false},
+	})
+	client := dial(t, mgr, WithMethodFeatures(map[string][]string{
+		checkMethod:                {"health"},
+		watchMethod:                {"streaming"},
+		"/grpc.health.v1.Health/*": {"health"},
+	}))
+
+	if err := check(client); err != nil {
+		t.Fatalf("expected licensed unary call to succeed, got %v", err)
+	}
+	assertDenied(t, watch(client), codes.PermissionDenied, "FEATURE_NOT_LICENSED")
+}
+
+func TestInterceptors_RequiredFeatures(t *testing.T) {
+	mgr := newManager(t, &cnwlicense.ValidateResponse{
+		Valid:    true,
+		Features: cnwlicense.Features{"health": true},
+	})
+
+	if err := watch(dial(t, mgr, WithRequiredFeatures("health"))); err != nil {
+		t.Fatalf("expected licensed stream to succeed, got %v", err)
+	}
+	client := dial(t, mgr, WithRequiredFeatures("health", "sso"))
+	assertDenied(t, check(client), codes.PermissionDenied, "FEATURE_NOT_LICENSED")
+	assertDenied(t, watch(client), codes.PermissionDenied, "FEATURE_NOT_LICENSED")
+}
+
+func TestInterceptors_NoLicense(t *testing.T) {
+	mgr := newManager(t, &cnwlicense.ValidateResponse{Valid: false, Reason: "license is not active"})
+	client := dial(t, mgr)
+
+	assertDenied(t, check(client), codes.FailedPrecondition, "LICENSE_REQUIRED")
+	assertDenied(t, watch(client), codes.FailedPrecondition, "LICENSE_REQUIRED")
+}
+
+func TestInterceptors_SkipMethods(t *testing.T) {
+	mgr := cnwlicense.NewManager() // never validated
+	client := dial(t, mgr, WithSkipMethods(checkMethod))
+
+	if _, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{}); status.Code(err) != codes.Internal {
+		t.Fatalf("expected skipped method to reach the handler without a license, got %v", err)
+	}
+	assertDenied(t, watch(client), codes.FailedPrecondition, "LICENSE_REQUIRED")
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{cnwlicense.ErrFeatureNotLicensed, codes.PermissionDenied, "FEATURE_NOT_LICENSED"},
+		{cnwlicense.ErrLicenseExpired, codes.FailedPrecondition, "LICENSE_EXPIRED"},
+		{cnwlicense.ErrLicenseInactive, codes.FailedPrecondition, "LICENSE_INACTIVE"},
+		{cnwlicense.ErrNoLicense, codes.FailedPrecondition, "LICENSE_REQUIRED"},
+	}
+	for _, tt := range tests {
+		assertDenied(t, Status(tt.err).Err(), tt.code, tt.reason)
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwgrpc
+
+go 1.25.6
+
+require (
+	github.com/CloudNativeWorks/cnw-license-sdk v0.3.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260120221211-b8f7ae30c516
+	google.golang.org/grpc v1.80.0
+)
+
+require (
+	golang.org/x/net v0.49.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
+
+// Build against the SDK in this repository. Users get the tagged SDK release
+// required above, since replace directives only apply in the main module.
+replace github.com/CloudNativeWorks/cnw-license-sdk => ../..
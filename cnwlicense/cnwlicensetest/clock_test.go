@@ -0,0 +1,114 @@
+package cnwlicensetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/signer"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(epoch)
+	if !clock.Now().Equal(epoch) {
+		t.Errorf("expected %v, got %v", epoch, clock.Now())
+	}
+	if got := clock.Advance(time.Hour); !got.Equal(epoch.Add(time.Hour)) || !clock.Now().Equal(got) {
+		t.Errorf("expected Advance to move the clock by 1h, got %v", got)
+	}
+	clock.Set(epoch.Add(-time.Hour))
+	if !clock.Now().Equal(epoch.Add(-time.Hour)) {
+		t.Errorf("expected Set to move the clock backwards, got %v", clock.Now())
+	}
+
+	var _ cnwlicense.Clock = clock
+}
+
+func TestFakeClock_OfflineValidator(t *testing.T) {
+	s, err := signer.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := epoch.Add(24 * time.Hour)
+	signed, err := s.Sign(cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		IssuedAt:   epoch,
+		NotBefore:  &notBefore,
+		ExpiresAt:  epoch.Add(30 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, _ := json.Marshal(signed)
+
+	clock := cnwlicensetest.NewFakeClock(epoch)
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithValidatorClock(clock),
+	)
+
+	if _, err := v.Verify(file); !errors.Is(err, cnwlicense.ErrLicenseNotYetValid) {
+		t.Errorf("expected ErrLicenseNotYetValid before not_before, got %v", err)
+	}
+	clock.Advance(48 * time.Hour)
+	if _, err := v.Verify(file); err != nil {
+		t.Errorf("expected license to verify within its validity window, got %v", err)
+	}
+	clock.Advance(30 * 24 * time.Hour)
+	if _, err := v.Verify(file); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired after expires_at, got %v", err)
+	}
+}
+
+func TestFakeClock_ManagerCacheGrace(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(time.Now())
+	expires := clock.Now().Add(365 * 24 * time.Hour)
+
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cnwlicense.ValidateResponse{Valid: true, Plan: "pro", ExpiresAt: &expires})
+	}))
+	defer server.Close()
+
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key",
+			cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithLicenseCache(cnwlicense.NewFileCacheStore(t.TempDir()), 72*time.Hour),
+		cnwlicense.WithCacheSecret([]byte("app-secret")),
+		cnwlicense.WithManagerClock(clock),
+	)
+	ctx := context.Background()
+
+	if _, err := mgr.ValidateAndEnforce(ctx, "CNW-CLOCK"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	down.Store(true)
+
+	clock.Advance(71 * time.Hour)
+	info, err := mgr.ValidateAndEnforce(ctx, "CNW-CLOCK")
+	if err != nil {
+		t.Fatalf("expected cached result within grace, got %v", err)
+	}
+	if info.Source != cnwlicense.SourceCache {
+		t.Errorf("expected source cache, got %s", info.Source)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if _, err := mgr.ValidateAndEnforce(ctx, "CNW-CLOCK"); !errors.Is(err, cnwlicense.ErrCacheGraceExpired) {
+		t.Errorf("expected ErrCacheGraceExpired after grace, got %v", err)
+	}
+}
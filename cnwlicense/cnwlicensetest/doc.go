@@ -0,0 +1,22 @@
+// Package cnwlicensetest provides helpers for testing code that uses the
+// cnwlicense package.
+//
+// FakeClock is a manually controlled cnwlicense.Clock:
+//
+//	clock := cnwlicensetest.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+//	v := cnwlicense.NewOfflineValidator(
+//	    cnwlicense.WithTrustedPublicKey(pubKey),
+//	    cnwlicense.WithValidatorClock(clock),
+//	)
+//	clock.Advance(365 * 24 * time.Hour) // the license is now expired
+//
+// Server is an in-memory fake of the license server that speaks the wire
+// format OnlineClient expects:
+//
+//	srv := cnwlicensetest.NewServer(t)
+//	srv.AddLicense(cnwlicensetest.License{Key: "CNW-TEST-0001", Plan: "enterprise"})
+//	client := srv.Client()
+//
+// Recorder records exchanges with a real license server to a golden file, with
+// API and license keys redacted, and replays them in later runs.
+package cnwlicensetest
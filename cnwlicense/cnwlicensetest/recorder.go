@@ -0,0 +1,404 @@
+package cnwlicensetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// RecorderMode selects whether a Recorder talks to a real server or replays a
+// golden file.
+type RecorderMode int
+
+const (
+	// Replay serves responses from the golden file and fails on requests that
+	// were not recorded.
+	Replay RecorderMode = iota
+	// Record forwards requests to the server and writes the exchanges to the
+	// golden file when the test ends.
+	Record
+)
+
+// ErrNoRecording is returned by a replaying Recorder for a request that has no
+// matching recorded exchange.
+var ErrNoRecording = errors.New("cnwlicensetest: no recorded response for request")
+
+// defaultRedactedFields are the JSON fields whose values never reach golden files.
+var defaultRedactedFields = []string{"license_key"}
+
+// minEmbeddedLength is the shortest redacted value that is also replaced inside
+// longer strings. Shorter values, such as "1" or "pro", would corrupt unrelated
+// text, so they are only replaced where a string equals them exactly.
+const minEmbeddedLength = 8
+
+// Recorder is an http.RoundTripper that records exchanges between an
+// OnlineClient and a license server to a golden file, or replays them, for
+// deterministic tests:
+//
+//	mode := cnwlicensetest.Replay
+//	if os.Getenv("RECORD") != "" {
+//	    mode = cnwlicensetest.Record
+//	}
+//	rec := cnwlicensetest.NewRecorder(t, "testdata/validate.json", mode)
+//	client := cnwlicense.NewOnlineClient(serverURL, apiKey, cnwlicense.WithHTTPClient(rec.Client()))
+//
+// The X-API-Key header is never recorded, and license keys (and any fields added
+// with WithRedactedFields) are replaced by placeholders such as
+// "redacted-license_key-1", wherever a string equals a redacted value or, for
+// values of at least 8 characters, contains it. Bodies that are not JSON, such
+// as a proxy's error page, have the values seen so far replaced the same way.
+// Replayed requests are redacted the same way, so a test may use a different
+// license key in replay than was used while recording; placeholders in replayed
+// responses are mapped back to the replaying test's values.
+//
+// Placeholders are numbered per field by first appearance within one run, not
+// looked up from the golden file: a replaying test that uses several license
+// keys must send them in the same order as the recording did.
+//
+// Requests are matched by method, path and redacted JSON body, in recorded order:
+// each recorded exchange is served once.
+type Recorder struct {
+	tb        testing.TB
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+	fields    []string
+
+	mu           sync.Mutex
+	interactions []*interaction
+	used         []bool
+	placeholders map[string]string // field + "\x00" + value -> placeholder
+	values       map[string]string // placeholder -> value
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithTransport sets the transport used to reach the server in Record mode.
+// Default: http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithRedactedFields redacts the values of additional JSON fields, at any depth,
+// in request and response bodies.
+func WithRedactedFields(fields ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.fields = append(r.fields, fields...)
+	}
+}
+
+// interaction is a recorded request/response pair in a golden file.
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	body
+}
+
+type recordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	body
+}
+
+// body holds a JSON body verbatim, or any other body as text.
+type body struct {
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+func (b body) bytes() []byte {
+	if len(b.Body) > 0 {
+		var buf bytes.Buffer
+		if json.Compact(&buf, b.Body) == nil {
+			return buf.Bytes()
+		}
+	}
+	return []byte(b.BodyText)
+}
+
+type goldenFile struct {
+	Interactions []*interaction `json:"interactions"`
+}
+
+// NewRecorder creates a Recorder for the golden file at path. In Replay mode
+// the file is loaded immediately; in Record mode it is written when tb's test
+// ends.
+func NewRecorder(tb testing.TB, path string, mode RecorderMode, opts ...RecorderOption) *Recorder {
+	tb.Helper()
+	r := &Recorder{
+		tb:           tb,
+		path:         path,
+		mode:         mode,
+		transport:    http.DefaultTransport,
+		fields:       slices.Clone(defaultRedactedFields),
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == Record {
+		tb.Cleanup(r.save)
+		return r
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("cnwlicensetest: read golden file (record it first): %v", err)
+	}
+	var golden goldenFile
+	if err := json.Unmarshal(raw, &golden); err != nil {
+		tb.Fatalf("cnwlicensetest: parse golden file %s: %v", path, err)
+	}
+	r.interactions = golden.Interactions
+	r.used = make([]bool, len(golden.Interactions))
+	return r
+}
+
+// Client returns an http.Client using the Recorder, for cnwlicense.WithHTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	recorded := recordedRequest{Method: req.Method, Path: req.URL.Path, body: r.redact(reqBody)}
+	r.mu.Unlock()
+
+	if r.mode == Record {
+		return r.record(req, reqBody, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, reqBody []byte, recorded recordedRequest) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	for _, h := range []string{"Date", "Content-Length", "Set-Cookie"} {
+		header.Del(h)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, &interaction{
+		Request:  recorded,
+		Response: recordedResponse{Status: resp.StatusCode, Header: header, body: r.redact(respBody)},
+	})
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded recordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	want := recorded.bytes()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Request.Method != recorded.Method || in.Request.Path != recorded.Path ||
+			!bytes.Equal(in.Request.bytes(), want) {
+			continue
+		}
+		r.used[i] = true
+		respBody := r.restore(in.Response.bytes())
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+	r.tb.Errorf("cnwlicensetest: unmatched request %s %s %s (golden file %s)", recorded.Method, recorded.Path, want, r.path)
+	return nil, fmt.Errorf("%w: %s %s", ErrNoRecording, recorded.Method, recorded.Path)
+}
+
+// save writes the recorded exchanges to the golden file.
+func (r *Recorder) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	raw, err := json.MarshalIndent(goldenFile{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		r.tb.Errorf("cnwlicensetest: marshal golden file: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		r.tb.Errorf("cnwlicensetest: create golden file directory: %v", err)
+		return
+	}
+	if err := os.WriteFile(r.path, append(raw, '\n'), 0o644); err != nil {
+		r.tb.Errorf("cnwlicensetest: write golden file: %v", err)
+	}
+}
+
+// redact returns b with redacted fields replaced by placeholders. Bodies that
+// are not JSON are kept as text, with the redacted values seen so far replaced
+// in the same way. r.mu must be held.
+func (r *Recorder) redact(b []byte) body {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return body{}
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body{BodyText: r.redactString(string(b))}
+	}
+	r.collect(v)
+	out, err := json.Marshal(mapStrings(v, r.redactString))
+	if err != nil {
+		return body{BodyText: r.redactString(string(b))}
+	}
+	return body{Body: out}
+}
+
+// redactString replaces s with a placeholder if it equals a redacted value, and
+// any occurrence of a long enough redacted value inside it. r.mu must be held.
+func (r *Recorder) redactString(s string) string {
+	for _, p := range r.byLength() {
+		value := r.values[p]
+		if s == value {
+			return p
+		}
+		if len(value) >= minEmbeddedLength {
+			s = strings.ReplaceAll(s, value, p)
+		}
+	}
+	return s
+}
+
+// collect assigns placeholders to the values of redacted fields in v.
+func (r *Recorder) collect(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if s, ok := val.(string); ok && s != "" && slices.Contains(r.fields, k) {
+				r.placeholder(k, s)
+				continue
+			}
+			r.collect(val)
+		}
+	case []any:
+		for _, val := range v {
+			r.collect(val)
+		}
+	}
+}
+
+// byLength returns the placeholders, longest value first, so that a value
+// containing another is replaced whole.
+func (r *Recorder) byLength() []string {
+	ps := slices.Collect(maps.Keys(r.values))
+	slices.SortFunc(ps, func(a, b string) int {
+		if n := len(r.values[b]) - len(r.values[a]); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	return ps
+}
+
+// mapStrings applies fn to every string in a decoded JSON value.
+func mapStrings(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		for k, val := range v {
+			v[k] = mapStrings(val, fn)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = mapStrings(val, fn)
+		}
+	}
+	return v
+}
+
+// placeholder returns the stable placeholder for a field value. r.mu must be held.
+func (r *Recorder) placeholder(field, value string) string {
+	key := field + "\x00" + value
+	if p, ok := r.placeholders[key]; ok {
+		return p
+	}
+	n := 1
+	for _, p := range r.placeholders {
+		if strings.HasPrefix(p, "redacted-"+field+"-") {
+			n++
+		}
+	}
+	p := fmt.Sprintf("redacted-%s-%d", field, n)
+	r.placeholders[key] = p
+	r.values[p] = value
+	return p
+}
+
+// restore replaces placeholders in a replayed body with the values the
+// replaying test used. r.mu must be held.
+func (r *Recorder) restore(b []byte) []byte {
+	if len(r.values) == 0 {
+		return b
+	}
+	// Longest placeholder first: "...-10" must not be replaced as "...-1".
+	ps := slices.Collect(maps.Keys(r.values))
+	slices.SortFunc(ps, func(a, b string) int { return len(b) - len(a) })
+	restoreString := func(s string) string {
+		for _, p := range ps {
+			s = strings.ReplaceAll(s, p, r.values[p])
+		}
+		return s
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []byte(restoreString(string(b)))
+	}
+	out, err := json.Marshal(mapStrings(v, restoreString))
+	if err != nil {
+		return b
+	}
+	return out
+}
@@ -0,0 +1,182 @@
+package cnwlicensetest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+// recordExchanges records a validation and an activation against a fake server
+// into golden.
+func recordExchanges(t *testing.T, golden string) {
+	t.Run("record", func(t *testing.T) {
+		srv := cnwlicensetest.NewServer(t, cnwlicensetest.WithAPIKey("super-secret-api-key"))
+		srv.AddLicense(cnwlicensetest.License{Key: "CNW-REAL-KEY", Plan: "enterprise", MaxActivations: 2})
+
+		rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Record)
+		client := cnwlicense.NewOnlineClient(srv.URL, "super-secret-api-key", cnwlicense.WithHTTPClient(rec.Client()))
+		if _, err := client.Validate(context.Background(), cnwlicense.ValidateRequest{LicenseKey: "CNW-REAL-KEY", Fingerprint: "fp"}); err != nil {
+			t.Fatalf("validate: %v", err)
+		}
+		if _, err := client.Activate(context.Background(), cnwlicense.ActivateRequest{LicenseKey: "CNW-REAL-KEY", Fingerprint: "fp", Hostname: "node-1"}); err != nil {
+			t.Fatalf("activate: %v", err)
+		}
+	})
+}
+
+func TestRecorder_RecordReplay(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "testdata", "exchanges.json")
+	recordExchanges(t, golden)
+
+	raw, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	for _, secret := range []string{"super-secret-api-key", "CNW-REAL-KEY"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("golden file contains %q:\n%s", secret, raw)
+		}
+	}
+	if !strings.Contains(string(raw), "redacted-license_key-1") {
+		t.Errorf("expected license key placeholder in golden file:\n%s", raw)
+	}
+
+	// Replay with no server and a different license key.
+	rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Replay)
+	client := cnwlicense.NewOnlineClient("http://license.invalid", "other-key", cnwlicense.WithHTTPClient(rec.Client()))
+	resp, err := client.Validate(context.Background(), cnwlicense.ValidateRequest{LicenseKey: "CNW-TEST-KEY", Fingerprint: "fp"})
+	if err != nil {
+		t.Fatalf("replayed validate: %v", err)
+	}
+	if !resp.Valid || resp.Plan != "enterprise" || resp.ActivationRemaining != 2 {
+		t.Errorf("unexpected replayed response: %+v", resp)
+	}
+	act, err := client.Activate(context.Background(), cnwlicense.ActivateRequest{LicenseKey: "CNW-TEST-KEY", Fingerprint: "fp", Hostname: "node-1"})
+	if err != nil {
+		t.Fatalf("replayed activate: %v", err)
+	}
+	if act.ID != "act-001" || act.Hostname != "node-1" {
+		t.Errorf("unexpected replayed activation: %+v", act)
+	}
+}
+
+// recordingTB captures Errorf calls so unmatched replays can be asserted.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder_ReplayUnmatched(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "exchanges.json")
+	recordExchanges(t, golden)
+
+	tb := &recordingTB{TB: t}
+	rec := cnwlicensetest.NewRecorder(tb, golden, cnwlicensetest.Replay)
+	client := cnwlicense.NewOnlineClient("http://license.invalid", "key", cnwlicense.WithHTTPClient(rec.Client()))
+	ctx := context.Background()
+
+	// A different body does not match.
+	_, err := client.Validate(ctx, cnwlicense.ValidateRequest{LicenseKey: "CNW-TEST-KEY", Fingerprint: "other-fp"})
+	if !errors.Is(err, cnwlicensetest.ErrNoRecording) || len(tb.errors) != 1 {
+		t.Errorf("expected ErrNoRecording and a test failure, got %v, %v", err, tb.errors)
+	}
+
+	// Each recorded exchange is served once.
+	req := cnwlicense.ValidateRequest{LicenseKey: "CNW-TEST-KEY", Fingerprint: "fp"}
+	if _, err := client.Validate(ctx, req); err != nil {
+		t.Fatalf("replayed validate: %v", err)
+	}
+	if _, err := client.Validate(ctx, req); !errors.Is(err, cnwlicensetest.ErrNoRecording) || len(tb.errors) != 2 {
+		t.Errorf("expected the second identical request to be unmatched, got %v, %v", err, tb.errors)
+	}
+}
+
+func TestRecorder_RedactsEmbeddedValues(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "exchanges.json")
+	t.Run("record", func(t *testing.T) {
+		srv := cnwlicensetest.NewServer(t)
+		srv.InjectFault("/v1/validate", cnwlicensetest.Fault{Status: 403, Code: "FORBIDDEN", Message: "license CNW-REAL-KEY is suspended"})
+		rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Record)
+		client := cnwlicense.NewOnlineClient(srv.URL, "key", cnwlicense.WithHTTPClient(rec.Client()))
+		client.Validate(context.Background(), cnwlicense.ValidateRequest{LicenseKey: "CNW-REAL-KEY"})
+	})
+	raw, _ := os.ReadFile(golden)
+	if strings.Contains(string(raw), "CNW-REAL-KEY") {
+		t.Errorf("golden file contains the license key:\n%s", raw)
+	}
+
+	rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Replay)
+	client := cnwlicense.NewOnlineClient("http://license.invalid", "key", cnwlicense.WithHTTPClient(rec.Client()))
+	_, err := client.Validate(context.Background(), cnwlicense.ValidateRequest{LicenseKey: "CNW-TEST-KEY"})
+	var se *cnwlicense.ServerError
+	if !errors.Is(err, cnwlicense.ErrLicenseInactive) || !errors.As(err, &se) || se.Message != "license CNW-TEST-KEY is suspended" {
+		t.Errorf("expected replayed error with the replaying key restored, got %v", err)
+	}
+}
+
+func TestRecorder_ShortValuesRedactedWhole(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "exchanges.json")
+	t.Run("record", func(t *testing.T) {
+		srv := cnwlicensetest.NewServer(t)
+		srv.AddLicense(cnwlicensetest.License{Key: "CNW-REAL-KEY", MaxActivations: 1})
+		rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Record, cnwlicensetest.WithRedactedFields("fingerprint"))
+		client := cnwlicense.NewOnlineClient(srv.URL, "key", cnwlicense.WithHTTPClient(rec.Client()))
+		if _, err := client.Activate(context.Background(), cnwlicense.ActivateRequest{LicenseKey: "CNW-REAL-KEY", Fingerprint: "node", Hostname: "node-1"}); err != nil {
+			t.Fatalf("activate: %v", err)
+		}
+	})
+	raw, _ := os.ReadFile(golden)
+	if strings.Contains(string(raw), `"node"`) || !strings.Contains(string(raw), "redacted-fingerprint-1") {
+		t.Errorf("golden file contains the fingerprint:\n%s", raw)
+	}
+	if !strings.Contains(string(raw), `"node-1"`) {
+		t.Errorf("expected a short redacted value to leave other strings intact:\n%s", raw)
+	}
+}
+
+func TestRecorder_RedactsTextBodies(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "exchanges.json")
+	post := func(t *testing.T, rec *cnwlicensetest.Recorder, url, key string) string {
+		t.Helper()
+		resp, err := rec.Client().Post(url+"/v1/validate", "application/json", strings.NewReader(`{"license_key":"`+key+`"}`))
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return string(raw)
+	}
+	t.Run("record", func(t *testing.T) {
+		// A proxy error page that echoes the license key.
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, "upstream failed for license CNW-REAL-KEY")
+		}))
+		defer srv.Close()
+		rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Record)
+		post(t, rec, srv.URL, "CNW-REAL-KEY")
+	})
+	raw, _ := os.ReadFile(golden)
+	if strings.Contains(string(raw), "CNW-REAL-KEY") || !strings.Contains(string(raw), "redacted-license_key-1") {
+		t.Errorf("golden file contains the license key:\n%s", raw)
+	}
+
+	rec := cnwlicensetest.NewRecorder(t, golden, cnwlicensetest.Replay)
+	if got := post(t, rec, "http://license.invalid", "CNW-TEST-KEY"); got != "upstream failed for license CNW-TEST-KEY" {
+		t.Errorf("expected the replaying key restored in the text body, got %q", got)
+	}
+}
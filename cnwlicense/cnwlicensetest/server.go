@@ -0,0 +1,562 @@
+package cnwlicensetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// Server is an in-memory fake of the CNW License Server. It speaks the wire
+// format OnlineClient expects (/v1/validate, /v1/activate, /v1/deactivate,
+// /v1/heartbeat, the {data: ...} wrapping and the {error: {code, message}}
+// format) and keeps licenses and activations in memory. It is safe for
+// concurrent use.
+type Server struct {
+	// URL is the server's base URL, for NewOnlineClient.
+	URL string
+
+	srv    *httptest.Server
+	apiKey string
+	clock  cnwlicense.Clock
+
+	mu          sync.Mutex
+	plans       map[string]cnwlicense.Features
+	licenses    map[string]*License
+	activations []*activation
+	nextID      int
+	faults      []*pathFault
+	latency     time.Duration
+	requests    []Request
+}
+
+// License is a license known to a fake Server.
+type License struct {
+	Key  string
+	Plan string
+	// Features are merged over the plan's features (see AddPlan).
+	Features         cnwlicense.Features
+	DegradedFeatures cnwlicense.Features
+	// ExpiresAt is when the license expires. Zero means never.
+	ExpiresAt time.Time
+	// MaxActivations limits concurrent machine activations. Zero means unlimited.
+	MaxActivations int
+	// Suspended licenses are rejected as inactive.
+	Suspended bool
+}
+
+type activation struct {
+	cnwlicense.ActivateResponse
+	licenseKey string
+}
+
+// Request is a request received by a fake Server.
+type Request struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the request body into v, e.g. a cnwlicense.ValidateRequest.
+func (r Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Fault is a failure injected into a fake Server's responses (see InjectFault).
+type Fault struct {
+	// Status is the HTTP status code. Default: 503.
+	Status int
+	// Code and Message form the error body. Code defaults to RATE_LIMITED for
+	// 429 and UNAVAILABLE otherwise.
+	Code    string
+	Message string
+	// RetryAfter, if positive, is sent as a Retry-After header in whole seconds.
+	RetryAfter time.Duration
+	// Times is the number of requests to fail. Zero means until ClearFaults.
+	Times int
+}
+
+type pathFault struct {
+	path string
+	Fault
+}
+
+// ServerOption configures a fake Server.
+type ServerOption func(*Server)
+
+// WithAPIKey makes the server reject requests whose X-API-Key header is not key
+// with 401 UNAUTHORIZED. By default any key is accepted.
+func WithAPIKey(key string) ServerOption {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithServerClock sets the time source used for license expiry and activation
+// timestamps, e.g. a FakeClock shared with the code under test.
+func WithServerClock(c cnwlicense.Clock) ServerOption {
+	return func(s *Server) {
+		s.clock = c
+	}
+}
+
+// NewServer starts a fake license server that is closed when tb's test ends.
+func NewServer(tb testing.TB, opts ...ServerOption) *Server {
+	tb.Helper()
+	s := &Server{
+		clock:    cnwlicense.SystemClock{},
+		plans:    make(map[string]cnwlicense.Features),
+		licenses: make(map[string]*License),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns an OnlineClient for the server, using the configured API key.
+func (s *Server) Client(opts ...cnwlicense.ClientOption) *cnwlicense.OnlineClient {
+	apiKey := s.apiKey
+	if apiKey == "" {
+		apiKey = "test-api-key"
+	}
+	return cnwlicense.NewOnlineClient(s.URL, apiKey, opts...)
+}
+
+// AddPlan defines a plan's features. Licenses on the plan inherit them.
+func (s *Server) AddPlan(name string, features cnwlicense.Features) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plans[name] = maps.Clone(features)
+}
+
+// AddLicense adds or replaces a license.
+func (s *Server) AddLicense(l License) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l.Features = maps.Clone(l.Features)
+	l.DegradedFeatures = maps.Clone(l.DegradedFeatures)
+	s.licenses[l.Key] = &l
+}
+
+// UpdateLicense applies update to the license with the given key, e.g. to
+// change its expiry or features mid-test. It reports whether the license
+// exists; update is not called for an unknown license.
+func (s *Server) UpdateLicense(key string, update func(*License)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.licenses[key]
+	if !ok {
+		return false
+	}
+	update(l)
+	l.Key = key
+	return true
+}
+
+// Suspend marks a license as suspended, or reinstates it. It reports whether
+// the license exists.
+func (s *Server) Suspend(key string, suspended bool) bool {
+	return s.UpdateLicense(key, func(l *License) { l.Suspended = suspended })
+}
+
+// RemoveLicense deletes a license and its activations.
+func (s *Server) RemoveLicense(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.licenses, key)
+	s.activations = slices.DeleteFunc(s.activations, func(a *activation) bool { return a.licenseKey == key })
+}
+
+// Activations returns the current activations of a license.
+func (s *Server) Activations(key string) []cnwlicense.ActivateResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []cnwlicense.ActivateResponse
+	for _, a := range s.activations {
+		if a.licenseKey == key {
+			out = append(out, a.ActivateResponse)
+		}
+	}
+	return out
+}
+
+// InjectFault makes requests to path fail with f. An empty path matches every
+// endpoint. Faults are checked in the order they were injected.
+func (s *Server) InjectFault(path string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &pathFault{path: path, Fault: f})
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// SetLatency delays every response by d, or until the request is canceled.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// RequestsTo returns the requests received for path, in order.
+func (s *Server) RequestsTo(path string) []Request {
+	var out []Request
+	for _, r := range s.Requests() {
+		if r.Path == path {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// AssertRequestCount fails tb unless exactly n requests were received for path.
+func (s *Server) AssertRequestCount(tb testing.TB, path string, n int) {
+	tb.Helper()
+	if got := len(s.RequestsTo(path)); got != n {
+		tb.Errorf("cnwlicensetest: expected %d request(s) to %s, got %d", n, path, got)
+	}
+}
+
+// AssertLastRequest decodes the body of the last request for path into v,
+// failing tb if there was none.
+func (s *Server) AssertLastRequest(tb testing.TB, path string, v any) {
+	tb.Helper()
+	reqs := s.RequestsTo(path)
+	if len(reqs) == 0 {
+		tb.Fatalf("cnwlicensetest: no requests to %s", path)
+	}
+	if err := reqs[len(reqs)-1].Decode(v); err != nil {
+		tb.Fatalf("cnwlicensetest: decode %s request: %v", path, err)
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	latency := s.latency
+	fault := s.takeFault(r.URL.Path)
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case r.Method != http.MethodPost:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	case s.apiKey != "" && r.Header.Get("X-API-Key") != s.apiKey:
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid API key")
+		return
+	case fault != nil:
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+		}
+		writeError(w, fault.Status, fault.Code, fault.Message)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/v1/validate":
+		s.validate(w, body)
+	case "/v1/activate":
+		s.activate(w, body)
+	case "/v1/deactivate":
+		s.deactivate(w, body)
+	case "/v1/heartbeat":
+		s.heartbeat(w, body)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "route not found")
+	}
+}
+
+// takeFault returns the first fault matching path, consuming one use of it.
+// s.mu must be held.
+func (s *Server) takeFault(path string) *Fault {
+	for i, f := range s.faults {
+		if f.path != "" && f.path != path {
+			continue
+		}
+		out := f.Fault
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+		if out.Status == 0 {
+			out.Status = http.StatusServiceUnavailable
+		}
+		if out.Code == "" {
+			out.Code = "UNAVAILABLE"
+			if out.Status == http.StatusTooManyRequests {
+				out.Code = "RATE_LIMITED"
+			}
+		}
+		if out.Message == "" {
+			out.Message = http.StatusText(out.Status)
+		}
+		return &out
+	}
+	return nil
+}
+
+func (s *Server) validate(w http.ResponseWriter, body []byte) {
+	var req cnwlicense.ValidateRequest
+	if !decodeRequest(w, body, &req) || !checkMetadata(w, req.Metadata) {
+		return
+	}
+	l, ok := s.licenses[req.LicenseKey]
+	if !ok {
+		writeJSON(w, http.StatusOK, cnwlicense.ValidateResponse{Valid: false, Reason: "license not found"})
+		return
+	}
+	resp := cnwlicense.ValidateResponse{
+		Valid:               true,
+		Plan:                l.Plan,
+		Features:            s.features(l),
+		DegradedFeatures:    l.DegradedFeatures,
+		ActivationRemaining: s.activationRemaining(l),
+	}
+	if !l.ExpiresAt.IsZero() {
+		exp := l.ExpiresAt
+		resp.ExpiresAt = &exp
+	}
+	if reason := s.rejection(l); reason != "" {
+		resp.Valid, resp.Reason = false, reason
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) activate(w http.ResponseWriter, body []byte) {
+	var req cnwlicense.ActivateRequest
+	if !decodeRequest(w, body, &req) || !checkMetadata(w, req.Metadata) {
+		return
+	}
+	if req.LicenseKey == "" || req.Fingerprint == "" || req.Hostname == "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "license_key, fingerprint and hostname are required")
+		return
+	}
+	l, ok := s.licenses[req.LicenseKey]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "license not found")
+		return
+	}
+	if reason := s.rejection(l); reason != "" {
+		writeError(w, http.StatusForbidden, "FORBIDDEN", reason)
+		return
+	}
+
+	now := s.clock.Now()
+	if a := s.findActivation("", req.LicenseKey, req.Fingerprint); a != nil {
+		// Re-activating the same machine refreshes its record.
+		a.Hostname, a.IP, a.OS, a.Metadata, a.LastSeenAt = req.Hostname, req.IP, req.OS, req.Metadata, now
+		writeData(w, a.ActivateResponse)
+		return
+	}
+	if l.MaxActivations > 0 && s.activationCount(l.Key) >= l.MaxActivations {
+		writeError(w, http.StatusConflict, "ACTIVATION_LIMIT", "activation limit reached")
+		return
+	}
+	s.nextID++
+	a := &activation{
+		licenseKey: l.Key,
+		ActivateResponse: cnwlicense.ActivateResponse{
+			ID:          fmt.Sprintf("act-%03d", s.nextID),
+			LicenseID:   licenseID(l.Key),
+			Fingerprint: req.Fingerprint,
+			Hostname:    req.Hostname,
+			IP:          req.IP,
+			OS:          req.OS,
+			Metadata:    req.Metadata,
+			ActivatedAt: now,
+			LastSeenAt:  now,
+			Plan:        l.Plan,
+			Features:    s.features(l),
+		},
+	}
+	s.activations = append(s.activations, a)
+	writeData(w, a.ActivateResponse)
+}
+
+func (s *Server) deactivate(w http.ResponseWriter, body []byte) {
+	var req cnwlicense.DeactivateRequest
+	if !decodeRequest(w, body, &req) {
+		return
+	}
+	a := s.findActivation(req.ActivationID, req.LicenseKey, req.Fingerprint)
+	if a == nil {
+		writeError(w, http.StatusNotFound, "ACTIVATION_NOT_FOUND", "activation not found")
+		return
+	}
+	s.activations = slices.DeleteFunc(s.activations, func(b *activation) bool { return b == a })
+	resp := cnwlicense.DeactivateResponse{
+		ID:            a.ID,
+		LicenseID:     a.LicenseID,
+		Fingerprint:   a.Fingerprint,
+		DeactivatedAt: s.clock.Now(),
+	}
+	if l, ok := s.licenses[a.licenseKey]; ok {
+		resp.ActivationRemaining = s.activationRemaining(l)
+	}
+	writeData(w, resp)
+}
+
+func (s *Server) heartbeat(w http.ResponseWriter, body []byte) {
+	var req cnwlicense.HeartbeatRequest
+	if !decodeRequest(w, body, &req) {
+		return
+	}
+	a := s.findActivation(req.ActivationID, req.LicenseKey, req.Fingerprint)
+	if a == nil {
+		writeError(w, http.StatusNotFound, "ACTIVATION_NOT_FOUND", "activation not found")
+		return
+	}
+	if reason := s.rejection(s.licenses[a.licenseKey]); reason != "" {
+		writeError(w, http.StatusForbidden, "FORBIDDEN", reason)
+		return
+	}
+	a.LastSeenAt = s.clock.Now()
+	writeData(w, cnwlicense.HeartbeatResponse{
+		ID:          a.ID,
+		LicenseID:   a.LicenseID,
+		Fingerprint: a.Fingerprint,
+		LastSeenAt:  a.LastSeenAt,
+	})
+}
+
+// rejection returns the server's reason for rejecting l, or "" if it is usable.
+func (s *Server) rejection(l *License) string {
+	switch {
+	case l == nil:
+		return "license not found"
+	case l.Suspended:
+		return "license is suspended"
+	case !l.ExpiresAt.IsZero() && !s.clock.Now().Before(l.ExpiresAt):
+		return "license expired"
+	}
+	return ""
+}
+
+// features returns l's plan features overlaid with its own.
+func (s *Server) features(l *License) cnwlicense.Features {
+	f := maps.Clone(s.plans[l.Plan])
+	if f == nil {
+		f = cnwlicense.Features{}
+	}
+	maps.Copy(f, l.Features)
+	return f
+}
+
+func (s *Server) activationCount(key string) int {
+	n := 0
+	for _, a := range s.activations {
+		if a.licenseKey == key {
+			n++
+		}
+	}
+	return n
+}
+
+// activationRemaining returns the free activation slots of l, or 0 if unlimited.
+func (s *Server) activationRemaining(l *License) int {
+	if l.MaxActivations <= 0 {
+		return 0
+	}
+	return max(l.MaxActivations-s.activationCount(l.Key), 0)
+}
+
+// findActivation looks an activation up by ID, or by license key and fingerprint.
+func (s *Server) findActivation(id, licenseKey, fingerprint string) *activation {
+	for _, a := range s.activations {
+		if id != "" && a.ID == id {
+			return a
+		}
+		if id == "" && licenseKey != "" && a.licenseKey == licenseKey && a.Fingerprint == fingerprint {
+			return a
+		}
+	}
+	return nil
+}
+
+// licenseID derives a stable license ID that does not reveal the key.
+func licenseID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "lic-" + hex.EncodeToString(sum[:6])
+}
+
+// decodeRequest decodes a JSON request body, writing a 400 response on failure.
+func decodeRequest(w http.ResponseWriter, body []byte, v any) bool {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return false
+	}
+	return true
+}
+
+// checkMetadata rejects non-string metadata values like the real server.
+func checkMetadata(w http.ResponseWriter, metadata map[string]interface{}) bool {
+	for k, v := range metadata {
+		if _, ok := v.(string); !ok {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", fmt.Sprintf("metadata value for %q must be a string", k))
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeData writes v wrapped in {data: ...}, like the server's Success() helper.
+func writeData(w http.ResponseWriter, v any) {
+	writeJSON(w, http.StatusOK, map[string]any{"data": v})
+}
+
+// writeError writes the server's error format: {"error": {"code", "message"}}.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]string{"code": code, "message": message}})
+}
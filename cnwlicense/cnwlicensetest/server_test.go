@@ -0,0 +1,200 @@
+package cnwlicensetest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+func TestServer_Validate(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := cnwlicensetest.NewServer(t, cnwlicensetest.WithServerClock(clock))
+	s.AddPlan("enterprise", cnwlicense.Features{"sso": true, "max_nodes": 10})
+	s.AddLicense(cnwlicensetest.License{
+		Key:            "CNW-VALID",
+		Plan:           "enterprise",
+		Features:       cnwlicense.Features{"max_nodes": 20},
+		ExpiresAt:      clock.Now().Add(30 * 24 * time.Hour),
+		MaxActivations: 3,
+	})
+	client := s.Client()
+	ctx := context.Background()
+
+	resp, err := client.Validate(ctx, cnwlicense.ValidateRequest{LicenseKey: "CNW-VALID"})
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !resp.Valid || resp.Plan != "enterprise" || resp.ActivationRemaining != 3 || resp.ExpiresAt == nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if !resp.Features.Bool("sso", false) || resp.Features.Int("max_nodes", 0) != 20 {
+		t.Errorf("expected plan features overlaid with license features, got %v", resp.Features)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		update func()
+		reason string
+	}{
+		{"not found", "CNW-MISSING", func() {}, "license not found"},
+		{"suspended", "CNW-VALID", func() { s.Suspend("CNW-VALID", true) }, "license is suspended"},
+		{"expired", "CNW-VALID", func() {
+			s.Suspend("CNW-VALID", false)
+			clock.Advance(31 * 24 * time.Hour)
+		}, "license expired"},
+	}
+	for _, tt := range tests {
+		tt.update()
+		resp, err := client.Validate(ctx, cnwlicense.ValidateRequest{LicenseKey: tt.key})
+		if err != nil {
+			t.Fatalf("%s: validate: %v", tt.name, err)
+		}
+		if resp.Valid || resp.Reason != tt.reason {
+			t.Errorf("%s: expected reason %q, got %+v", tt.name, tt.reason, resp)
+		}
+	}
+
+	_, err = client.Validate(ctx, cnwlicense.ValidateRequest{
+		LicenseKey: "CNW-VALID",
+		Metadata:   map[string]interface{}{"cpus": 8},
+	})
+	if !errors.Is(err, cnwlicense.ErrInvalidMetadata) {
+		t.Errorf("expected ErrInvalidMetadata, got %v", err)
+	}
+}
+
+func TestServer_Activations(t *testing.T) {
+	s := cnwlicensetest.NewServer(t)
+	s.AddLicense(cnwlicensetest.License{Key: "CNW-KEY", Plan: "pro", MaxActivations: 1})
+	client := s.Client()
+	ctx := context.Background()
+	activate := func(fp string) (*cnwlicense.ActivateResponse, error) {
+		return client.Activate(ctx, cnwlicense.ActivateRequest{LicenseKey: "CNW-KEY", Fingerprint: fp, Hostname: "host-" + fp})
+	}
+
+	first, err := activate("fp-1")
+	if err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if first.ID == "" || first.Plan != "pro" || first.Fingerprint != "fp-1" {
+		t.Errorf("unexpected activation: %+v", first)
+	}
+	again, err := activate("fp-1")
+	if err != nil || again.ID != first.ID {
+		t.Errorf("expected re-activation to return %s, got %+v, %v", first.ID, again, err)
+	}
+	if _, err := activate("fp-2"); !errors.Is(err, cnwlicense.ErrActivationLimit) {
+		t.Errorf("expected ErrActivationLimit, got %v", err)
+	}
+
+	hb, err := client.Heartbeat(ctx, cnwlicense.HeartbeatRequest{ActivationID: first.ID})
+	if err != nil || hb.ID != first.ID {
+		t.Errorf("heartbeat: %+v, %v", hb, err)
+	}
+
+	resp, err := client.Deactivate(ctx, cnwlicense.DeactivateRequest{LicenseKey: "CNW-KEY", Fingerprint: "fp-1"})
+	if err != nil || resp.ActivationRemaining != 1 {
+		t.Errorf("deactivate: %+v, %v", resp, err)
+	}
+	if _, err := client.Deactivate(ctx, cnwlicense.DeactivateRequest{ActivationID: first.ID}); !errors.Is(err, cnwlicense.ErrActivationNotFound) {
+		t.Errorf("expected ErrActivationNotFound, got %v", err)
+	}
+	if _, err := activate("fp-2"); err != nil {
+		t.Errorf("expected the released slot to be reusable, got %v", err)
+	}
+	if got := s.Activations("CNW-KEY"); len(got) != 1 || got[0].Fingerprint != "fp-2" {
+		t.Errorf("unexpected activations: %+v", got)
+	}
+
+	if _, err := client.Activate(ctx, cnwlicense.ActivateRequest{LicenseKey: "CNW-MISSING", Fingerprint: "fp", Hostname: "h"}); !errors.Is(err, cnwlicense.ErrLicenseNotFound) {
+		t.Errorf("expected ErrLicenseNotFound, got %v", err)
+	}
+	s.UpdateLicense("CNW-KEY", func(l *cnwlicensetest.License) { l.ExpiresAt = time.Now().Add(-time.Hour) })
+	if _, err := activate("fp-3"); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired, got %v", err)
+	}
+	s.Suspend("CNW-KEY", true)
+	if _, err := activate("fp-3"); !errors.Is(err, cnwlicense.ErrLicenseInactive) {
+		t.Errorf("expected ErrLicenseInactive, got %v", err)
+	}
+}
+
+func TestServer_UpdateLicense_Unknown(t *testing.T) {
+	s := cnwlicensetest.NewServer(t)
+	if s.UpdateLicense("CNW-MISSING", func(*cnwlicensetest.License) { t.Error("update called for an unknown license") }) {
+		t.Error("expected UpdateLicense to report an unknown license")
+	}
+	if s.Suspend("CNW-MISSING", true) {
+		t.Error("expected Suspend to report an unknown license")
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	s := cnwlicensetest.NewServer(t)
+	s.AddLicense(cnwlicensetest.License{Key: "CNW-KEY"})
+	ctx := context.Background()
+	req := cnwlicense.ValidateRequest{LicenseKey: "CNW-KEY"}
+
+	// A transient failure is retried by a client with a retry policy.
+	s.InjectFault("/v1/validate", cnwlicensetest.Fault{Status: http.StatusServiceUnavailable, Times: 1})
+	client := s.Client(cnwlicense.WithRetry(cnwlicense.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+	if _, err := client.Validate(ctx, req); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	s.AssertRequestCount(t, "/v1/validate", 2)
+
+	s.InjectFault("", cnwlicensetest.Fault{Status: http.StatusTooManyRequests, RetryAfter: 3 * time.Second})
+	_, err := s.Client().Validate(ctx, req)
+	var se *cnwlicense.ServerError
+	if !errors.Is(err, cnwlicense.ErrRateLimited) || !errors.As(err, &se) || se.RetryAfter != 3*time.Second {
+		t.Errorf("expected ErrRateLimited with Retry-After 3s, got %v", err)
+	}
+	s.ClearFaults()
+	if _, err := s.Client().Validate(ctx, req); err != nil {
+		t.Errorf("expected success after ClearFaults, got %v", err)
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	s := cnwlicensetest.NewServer(t)
+	s.AddLicense(cnwlicensetest.License{Key: "CNW-KEY"})
+	s.SetLatency(time.Second)
+
+	client := s.Client(cnwlicense.WithTimeout(20 * time.Millisecond))
+	if _, err := client.Validate(context.Background(), cnwlicense.ValidateRequest{LicenseKey: "CNW-KEY"}); err == nil {
+		t.Fatal("expected a timeout")
+	}
+}
+
+func TestServer_APIKeyAndRequests(t *testing.T) {
+	s := cnwlicensetest.NewServer(t, cnwlicensetest.WithAPIKey("secret"))
+	s.AddLicense(cnwlicensetest.License{Key: "CNW-KEY", Plan: "pro"})
+
+	_, err := cnwlicense.NewOnlineClient(s.URL, "wrong").Validate(context.Background(), cnwlicense.ValidateRequest{LicenseKey: "CNW-KEY"})
+	var se *cnwlicense.ServerError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %v", err)
+	}
+
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(s.Client(cnwlicense.WithFingerprint("node-fp"))))
+	info, err := mgr.ValidateAndEnforce(context.Background(), "CNW-KEY")
+	if err != nil || !info.Valid || info.Plan != "pro" {
+		t.Fatalf("unexpected result: %+v, %v", info, err)
+	}
+
+	var req cnwlicense.ValidateRequest
+	s.AssertLastRequest(t, "/v1/validate", &req)
+	if req.LicenseKey != "CNW-KEY" || req.Fingerprint != "node-fp" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if got := s.RequestsTo("/v1/validate"); got[len(got)-1].Header.Get("X-API-Key") != "secret" {
+		t.Errorf("expected API key header to be recorded")
+	}
+	s.AssertRequestCount(t, "/v1/activate", 0)
+}
@@ -1,8 +1,14 @@
 package cnwlicense
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Sentinel errors for license validation failures.
@@ -10,8 +16,11 @@ var (
 	ErrLicenseNotFound = errors.New("license not found")
 	ErrLicenseInactive = errors.New("license is not active")
 	ErrLicenseExpired  = errors.New("license expired")
-	ErrActivationLimit  = errors.New("activation limit reached")
-	ErrInvalidMetadata  = errors.New("invalid metadata")
+	ErrActivationLimit = errors.New("activation limit reached")
+	ErrInvalidMetadata = errors.New("invalid metadata")
+	ErrRateLimited     = errors.New("rate limited by license server")
+
+	ErrActivationNotFound = errors.New("activation not found")
 )
 
 // Sentinel errors for offline license verification.
@@ -19,6 +28,39 @@ var (
 	ErrSignatureInvalid   = errors.New("signature verification failed")
 	ErrPublicKeyInvalid   = errors.New("invalid public key")
 	ErrLicenseFileInvalid = errors.New("invalid license file format")
+	ErrLicenseKeyMismatch = errors.New("license key does not match license file")
+	ErrKeyRetired         = errors.New("signing key is not valid at this time")
+
+	ErrLicenseNotYetValid    = errors.New("license is not yet valid")
+	ErrLicenseIssuedInFuture = errors.New("license issued_at is in the future")
+	ErrClockTampered         = errors.New("clock rollback or tampering detected")
+
+	ErrFingerprintMismatch   = errors.New("license is not valid for this machine")
+	ErrLicenseRevoked        = errors.New("license revoked")
+	ErrRevocationListInvalid = errors.New("invalid revocation list")
+
+	ErrActivationRequestInvalid   = errors.New("invalid offline activation request")
+	ErrActivationRequestExpired   = errors.New("offline activation request expired")
+	ErrActivationResponseMismatch = errors.New("activation response does not answer this request")
+)
+
+// Sentinel errors for the last-known-good license cache.
+var (
+	ErrCacheMiss         = errors.New("no cached license")
+	ErrCacheTampered     = errors.New("license cache invalid or tampered")
+	ErrCacheGraceExpired = errors.New("cached license grace period ended")
+)
+
+// Sentinel errors for typed feature access (see Features).
+var (
+	ErrFeatureNotFound = errors.New("feature not found")
+	ErrFeatureInvalid  = errors.New("feature has an invalid value")
+)
+
+// Sentinel errors for license authorization (see Manager.Authorize).
+var (
+	ErrNoLicense          = errors.New("no valid license")
+	ErrFeatureNotLicensed = errors.New("feature not licensed")
 )
 
 // Sentinel errors for hardware limit enforcement.
@@ -33,6 +75,9 @@ type ServerError struct {
 	StatusCode int
 	Code       string
 	Message    string
+	// RetryAfter is the delay requested by the server via the Retry-After header
+	// (typically on 429 responses). 0 if the header was absent or unparseable.
+	RetryAfter time.Duration
 }
 
 func (e *ServerError) Error() string {
@@ -45,6 +90,8 @@ func (e *ServerError) Error() string {
 func mapServerError(se *ServerError) error {
 	var sentinel error
 	switch se.Code {
+	case "RATE_LIMITED":
+		sentinel = ErrRateLimited
 	case "NOT_FOUND":
 		sentinel = ErrLicenseNotFound
 	case "FORBIDDEN":
@@ -55,10 +102,15 @@ func mapServerError(se *ServerError) error {
 		}
 	case "ACTIVATION_LIMIT":
 		sentinel = ErrActivationLimit
+	case "ACTIVATION_NOT_FOUND":
+		sentinel = ErrActivationNotFound
 	case "VALIDATION_ERROR":
 		sentinel = ErrInvalidMetadata
 	default:
-		return se
+		if se.StatusCode != http.StatusTooManyRequests {
+			return se
+		}
+		sentinel = ErrRateLimited
 	}
 	return &mappedError{sentinel: sentinel, server: se}
 }
@@ -88,3 +140,58 @@ func (e *mappedError) As(target interface{}) bool {
 func (e *mappedError) Unwrap() error {
 	return e.sentinel
 }
+
+// isUnavailable reports whether err means the license server could not give an
+// answer (network failure, timeout, throttling, 502/503/504), as opposed to an
+// explicit rejection such as ErrLicenseNotFound. Caller cancellation is not
+// considered unavailability.
+func isUnavailable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var me *mappedError
+	if errors.As(err, &me) {
+		return false
+	}
+	var se *ServerError
+	if errors.As(err, &se) {
+		switch se.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
+
+// isRejection reports whether err is an explicit rejection of the license by the server.
+func isRejection(err error) bool {
+	return errors.Is(err, ErrLicenseNotFound) ||
+		errors.Is(err, ErrLicenseInactive) ||
+		errors.Is(err, ErrLicenseExpired)
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a number of
+// seconds or an HTTP-date. Returns 0 if the value is empty, invalid, or in the past.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package cnwlicense
+
+import (
+	"slices"
+	"time"
+)
+
+// ExpiryWarning is passed to the expiry warning handler when a license's
+// remaining validity crosses one of the configured thresholds.
+type ExpiryWarning struct {
+	// Threshold is the crossed threshold (e.g. 7 days).
+	Threshold time.Duration
+	// ExpiresAt and ExpiresIn describe when the license expires.
+	ExpiresAt time.Time
+	ExpiresIn time.Duration
+	// Info is a copy of the validation result that crossed the threshold.
+	Info *LicenseInfo
+}
+
+// WithExpiryWarnings calls handler once per threshold (e.g. 30, 7 and 1 days) when a
+// valid license's remaining validity drops to or below it, for online, cached and
+// offline results alike. If several thresholds are crossed at once, only the
+// smallest is reported. Warnings start over when the license is renewed (its
+// expiry moves). The handler runs synchronously on the validating goroutine.
+func WithExpiryWarnings(handler func(ExpiryWarning), thresholds ...time.Duration) ManagerOption {
+	return func(m *Manager) {
+		sorted := slices.Clone(thresholds)
+		slices.Sort(sorted)
+		slices.Reverse(sorted)
+		m.expiryWarnings = sorted
+		m.onExpiryWarning = handler
+	}
+}
+
+// expiryWarning returns the warning to report for info, if it crosses a threshold
+// that has not been reported yet for its expiry. Must be called with stateMu held.
+func (m *Manager) expiryWarning(info *LicenseInfo) *ExpiryWarning {
+	if m.onExpiryWarning == nil || !info.Valid || info.ExpiresAt == nil ||
+		(info.Status != StatusValid && info.Status != StatusExpiring) {
+		return nil
+	}
+	if !info.ExpiresAt.Equal(m.warnedExpiresAt) {
+		m.warnedExpiresAt = *info.ExpiresAt
+		m.warnedLevel = 0
+	}
+
+	level := 0
+	for _, t := range m.expiryWarnings {
+		if info.ExpiresIn <= t {
+			level++
+		}
+	}
+	if level <= m.warnedLevel {
+		return nil
+	}
+	m.warnedLevel = level
+	return &ExpiryWarning{
+		Threshold: m.expiryWarnings[level-1],
+		ExpiresAt: *info.ExpiresAt,
+		ExpiresIn: info.ExpiresIn,
+		Info:      info,
+	}
+}
@@ -0,0 +1,137 @@
+package cnwlicense_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+const day = 24 * time.Hour
+
+func TestManager_ExpiryWarnings(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(time.Now())
+	var expiresAt atomic.Pointer[time.Time]
+	exp := clock.Now().Add(40 * day)
+	expiresAt.Store(&exp)
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true, ExpiresAt: expiresAt.Load()}
+	})
+
+	var warnings []cnwlicense.ExpiryWarning
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithManagerClock(clock),
+		cnwlicense.WithExpiryWarnings(func(w cnwlicense.ExpiryWarning) { warnings = append(warnings, w) }, day, 30*day, 7*day),
+	)
+	validate := func() *cnwlicense.LicenseInfo {
+		t.Helper()
+		info, err := mgr.ValidateAndEnforce(context.Background(), "CNW-EXPIRY")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return info
+	}
+
+	info := validate()
+	if info.ExpiresIn != 40*day {
+		t.Errorf("expected ExpiresIn 40d, got %v", info.ExpiresIn)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		want    []time.Duration // thresholds reported so far
+	}{
+		{15 * day, []time.Duration{30 * day}},
+		{time.Hour, []time.Duration{30 * day}}, // one-shot
+		{20 * day, []time.Duration{30 * day, 7 * day}},
+		{4*day + 12*time.Hour, []time.Duration{30 * day, 7 * day, day}},
+		{time.Hour, []time.Duration{30 * day, 7 * day, day}},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		validate()
+		if len(warnings) != len(step.want) {
+			t.Fatalf("step %d: expected %d warnings, got %d", i, len(step.want), len(warnings))
+		}
+		for j, w := range step.want {
+			if warnings[j].Threshold != w {
+				t.Errorf("step %d: warning %d threshold = %v, want %v", i, j, warnings[j].Threshold, w)
+			}
+		}
+	}
+	last := warnings[len(warnings)-1]
+	if !last.ExpiresAt.Equal(exp) || last.ExpiresIn <= 0 || last.ExpiresIn > day || last.Info == nil {
+		t.Errorf("unexpected warning details: %+v", last)
+	}
+
+	// Renewal moves the expiry and starts the warnings over.
+	renewed := clock.Now().Add(365 * day)
+	expiresAt.Store(&renewed)
+	validate()
+	if len(warnings) != 3 {
+		t.Fatalf("expected no warning after renewal, got %d", len(warnings))
+	}
+	clock.Advance(340 * day)
+	validate()
+	if len(warnings) != 4 || warnings[3].Threshold != 30*day {
+		t.Errorf("expected 30d warning for the renewed license, got %+v", warnings[3:])
+	}
+}
+
+func TestManager_ExpiryWarnings_SmallestCrossedThreshold(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-EXPIRY",
+		ExpiresAt:  time.Now().Add(5 * day),
+		IssuedAt:   time.Now(),
+	})
+	var warnings []cnwlicense.ExpiryWarning
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+		cnwlicense.WithExpiryWarnings(func(w cnwlicense.ExpiryWarning) { warnings = append(warnings, w) }, 30*day, 7*day, day),
+	)
+
+	info, err := mgr.ValidateOffline("CNW-EXPIRY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.ExpiresIn <= 4*day || info.ExpiresIn > 5*day {
+		t.Errorf("expected ExpiresIn ~5d, got %v", info.ExpiresIn)
+	}
+	if len(warnings) != 1 || warnings[0].Threshold != 7*day {
+		t.Errorf("expected a single 7d warning, got %+v", warnings)
+	}
+	mgr.ValidateOffline("CNW-EXPIRY")
+	if len(warnings) != 1 {
+		t.Errorf("expected no repeated warning, got %d", len(warnings))
+	}
+}
+
+func TestManager_ExpiryWarnings_NotInGrace(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-EXPIRY",
+		ExpiresAt:  time.Now().Add(-time.Hour),
+		IssuedAt:   time.Now().Add(-day),
+	})
+	var warnings int
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+		cnwlicense.WithManagerExpiryGrace(7*day),
+		cnwlicense.WithExpiryWarnings(func(cnwlicense.ExpiryWarning) { warnings++ }, day),
+	)
+	info, err := mgr.ValidateOffline("CNW-EXPIRY")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.ExpiresIn >= 0 {
+		t.Errorf("expected negative ExpiresIn during grace, got %v", info.ExpiresIn)
+	}
+	if warnings != 0 {
+		t.Errorf("expected no expiry warning for an expired license, got %d", warnings)
+	}
+}
@@ -0,0 +1,238 @@
+package cnwlicense
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Features is the feature map of a license, as returned by the server or stored in
+// an offline license file. It is a plain map, so values can still be read directly,
+// but the typed accessors below cope with the representations JSON produces:
+// numbers may be float64, json.Number or numeric strings, booleans may be strings
+// such as "true".
+//
+// Each accessor returns the given default when the feature is missing or cannot be
+// converted; the E variants return ErrFeatureNotFound or ErrFeatureInvalid instead.
+// A feature with a null value counts as missing.
+type Features map[string]interface{}
+
+// Has reports whether the feature is present with a non-null value.
+func (f Features) Has(key string) bool {
+	return f[key] != nil
+}
+
+// Bool returns the feature as a bool, or def.
+func (f Features) Bool(key string, def bool) bool {
+	if b, err := f.BoolE(key); err == nil {
+		return b
+	}
+	return def
+}
+
+// BoolE returns the feature as a bool. Accepts booleans, strings understood by
+// strconv.ParseBool ("true", "1", "false", ...) and numbers (non-zero is true).
+func (f Features) BoolE(key string) (bool, error) {
+	v, err := f.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+		if err != nil {
+			return false, invalidFeature(key, v, "bool")
+		}
+		return parsed, nil
+	}
+	if n, ok := numberValue(v); ok {
+		return n != 0, nil
+	}
+	return false, invalidFeature(key, v, "bool")
+}
+
+// Int returns the feature as an int, or def.
+func (f Features) Int(key string, def int) int {
+	if n, err := f.IntE(key); err == nil {
+		return n
+	}
+	return def
+}
+
+// IntE returns the feature as an int. Accepts any number or numeric string;
+// fractional values are truncated toward zero.
+func (f Features) IntE(key string) (int, error) {
+	n, err := f.FloatE(key)
+	if err != nil {
+		return 0, err
+	}
+	// float64(math.MaxInt) rounds up to 2^63, which does not fit in an int, so
+	// the upper bound is exclusive.
+	if n >= -math.MinInt || n < math.MinInt {
+		return 0, invalidFeature(key, f[key], "int")
+	}
+	return int(n), nil
+}
+
+// Float returns the feature as a float64, or def.
+func (f Features) Float(key string, def float64) float64 {
+	if n, err := f.FloatE(key); err == nil {
+		return n
+	}
+	return def
+}
+
+// FloatE returns the feature as a float64. Accepts any finite number or numeric
+// string; NaN and infinities are invalid.
+func (f Features) FloatE(key string) (float64, error) {
+	v, err := f.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := numberValue(v)
+	if s, isString := v.(string); isString {
+		var err error
+		n, err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+		ok = err == nil
+	}
+	if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, invalidFeature(key, v, "number")
+	}
+	return n, nil
+}
+
+// String returns the feature as a string, or def.
+func (f Features) String(key string, def string) string {
+	if s, err := f.StringE(key); err == nil {
+		return s
+	}
+	return def
+}
+
+// StringE returns the feature as a string. Only strings (and json.Number) are accepted.
+func (f Features) StringE(key string) (string, error) {
+	v, err := f.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	switch s := v.(type) {
+	case string:
+		return s, nil
+	case json.Number:
+		return s.String(), nil
+	}
+	return "", invalidFeature(key, v, "string")
+}
+
+// Duration returns the feature as a time.Duration, or def.
+func (f Features) Duration(key string, def time.Duration) time.Duration {
+	if d, err := f.DurationE(key); err == nil {
+		return d
+	}
+	return def
+}
+
+// DurationE returns the feature as a time.Duration. Accepts strings understood by
+// time.ParseDuration ("720h", "30m") and numbers, which are taken as seconds.
+func (f Features) DurationE(key string) (time.Duration, error) {
+	v, err := f.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+			return d, nil
+		}
+		return 0, invalidFeature(key, v, "duration")
+	}
+	if n, ok := numberValue(v); ok {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	return 0, invalidFeature(key, v, "duration")
+}
+
+// StringSlice returns the feature as a []string, or def.
+func (f Features) StringSlice(key string, def []string) []string {
+	if s, err := f.StringSliceE(key); err == nil {
+		return s
+	}
+	return def
+}
+
+// StringSliceE returns the feature as a []string. Accepts arrays of strings and
+// comma-separated strings ("a, b" yields ["a", "b"]).
+func (f Features) StringSliceE(key string) ([]string, error) {
+	v, err := f.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	switch s := v.(type) {
+	case []string:
+		return s, nil
+	case []interface{}:
+		out := make([]string, len(s))
+		for i, item := range s {
+			str, ok := item.(string)
+			if !ok {
+				return nil, invalidFeature(key, v, "string list")
+			}
+			out[i] = str
+		}
+		return out, nil
+	case string:
+		parts := strings.Split(s, ",")
+		out := make([]string, 0, len(parts))
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+		return out, nil
+	}
+	return nil, invalidFeature(key, v, "string list")
+}
+
+// lookup returns the feature's value or ErrFeatureNotFound.
+func (f Features) lookup(key string) (interface{}, error) {
+	v := f[key]
+	if v == nil {
+		return nil, fmt.Errorf("%w: %q", ErrFeatureNotFound, key)
+	}
+	return v, nil
+}
+
+// numberValue converts a numeric JSON or Go value to float64.
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// invalidFeature returns an ErrFeatureInvalid error for a value of the wrong type.
+func invalidFeature(key string, v interface{}, want string) error {
+	return fmt.Errorf("%w: %q is %T %v, want %s", ErrFeatureInvalid, key, v, v, want)
+}
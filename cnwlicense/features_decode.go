@@ -0,0 +1,250 @@
+package cnwlicense
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// featureTag is the struct tag read by Features.Decode.
+const featureTag = "cnwfeature"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Decode copies features into the struct pointed to by dst, using the cnwfeature
+// struct tag to map keys to fields:
+//
+//	type Entitlements struct {
+//	    MaxUsers  int           `cnwfeature:"max_users,required,min=1"`
+//	    SSO       bool          `cnwfeature:"sso,default=false"`
+//	    Retention time.Duration `cnwfeature:"log_retention,default=720h,max=8760h"`
+//	    Regions   []string      `cnwfeature:"regions"`
+//	}
+//
+// The tag holds the feature key followed by options:
+//   - default=<value>: used when the feature is missing; parsed like a feature value
+//     (so it cannot contain a comma)
+//   - required: the feature must be present, otherwise ErrFeatureNotFound
+//   - min=<value>, max=<value>: inclusive bounds for numeric and time.Duration fields
+//
+// Supported field types are bool, string, integer and float kinds, time.Duration,
+// []string and nested structs (decoded from a nested object; a missing object is
+// decoded as empty, so its fields' defaults and required options still apply).
+// Fields without the tag, or tagged "-", are left untouched, as are missing
+// features without a default.
+//
+// Conversions follow the Features accessors, so "true", json.Number and numeric
+// strings are accepted. All offending keys are reported together; each error wraps
+// ErrFeatureNotFound or ErrFeatureInvalid and names the key.
+func (f Features) Decode(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cnwfeature: Decode requires a non-nil pointer to a struct, got %T", dst)
+	}
+	return f.decodeStruct(rv.Elem(), "")
+}
+
+// decodeStruct decodes f into the struct v. prefix is the dotted path of v's
+// key, used in error messages for nested structs.
+func (f Features) decodeStruct(v reflect.Value, prefix string) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup(featureTag)
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		opts, err := parseFeatureTag(tag)
+		if err != nil {
+			return fmt.Errorf("cnwfeature: field %s: %w", field.Name, err)
+		}
+		if err := f.decodeField(v.Field(i), field, opts, prefix); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// featureTagOptions is a parsed cnwfeature tag.
+type featureTagOptions struct {
+	key      string
+	required bool
+	def      *string
+	min, max *string
+}
+
+func parseFeatureTag(tag string) (featureTagOptions, error) {
+	parts := strings.Split(tag, ",")
+	opts := featureTagOptions{key: strings.TrimSpace(parts[0])}
+	if opts.key == "" {
+		return opts, errors.New("empty feature key")
+	}
+	for _, p := range parts[1:] {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(p), "=")
+		switch {
+		case name == "required" && !hasValue:
+			opts.required = true
+		case name == "default" && hasValue:
+			opts.def = &value
+		case name == "min" && hasValue:
+			opts.min = &value
+		case name == "max" && hasValue:
+			opts.max = &value
+		default:
+			return opts, fmt.Errorf("unknown option %q", p)
+		}
+	}
+	return opts, nil
+}
+
+// decodeField decodes a single feature into the field fv.
+func (f Features) decodeField(fv reflect.Value, field reflect.StructField, opts featureTagOptions, prefix string) error {
+	path := prefix + opts.key
+	nestedStruct := fv.Kind() == reflect.Struct && fv.Type() != durationType
+	src := f
+	if !f.Has(opts.key) {
+		switch {
+		case opts.required:
+			return fmt.Errorf("%w: %q is required", ErrFeatureNotFound, path)
+		case nestedStruct && opts.def == nil:
+			// Decode an absent object as empty, so that its fields' required
+			// and default options still apply.
+			return Features{}.decodeStruct(fv, path+".")
+		case opts.def == nil:
+			return nil
+		}
+		src = Features{opts.key: *opts.def}
+	}
+
+	if nestedStruct {
+		var nested Features
+		switch m := src[opts.key].(type) {
+		case map[string]interface{}:
+			nested = m
+		case Features:
+			nested = m
+		default:
+			return invalidFeature(path, src[opts.key], "object")
+		}
+		return nested.decodeStruct(fv, path+".")
+	}
+
+	value, err := src.convert(opts.key, fv.Type())
+	if err != nil {
+		// Re-key the error with the full path for nested structs.
+		if prefix != "" {
+			return fmt.Errorf("%w (in %q)", err, path)
+		}
+		return err
+	}
+	if err := checkFeatureRange(path, value, fv.Type(), opts); err != nil {
+		return err
+	}
+	fv.Set(value)
+	return nil
+}
+
+// convert reads key as a value of type t using the typed accessors.
+func (f Features) convert(key string, t reflect.Type) (reflect.Value, error) {
+	if t == durationType {
+		d, err := f.DurationE(key)
+		return reflect.ValueOf(d), err
+	}
+	rv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := f.BoolE(key)
+		rv.SetBool(b)
+		return rv, err
+	case reflect.String:
+		s, err := f.StringE(key)
+		rv.SetString(s)
+		return rv, err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := f.IntE(key)
+		if err == nil && rv.OverflowInt(int64(n)) {
+			err = invalidFeature(key, f[key], t.String())
+		}
+		rv.SetInt(int64(n))
+		return rv, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := f.IntE(key)
+		if err == nil && (n < 0 || rv.OverflowUint(uint64(n))) {
+			err = invalidFeature(key, f[key], t.String())
+		}
+		if err == nil {
+			rv.SetUint(uint64(n))
+		}
+		return rv, err
+	case reflect.Float32, reflect.Float64:
+		n, err := f.FloatE(key)
+		rv.SetFloat(n)
+		return rv, err
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			s, err := f.StringSliceE(key)
+			if err != nil {
+				return rv, err
+			}
+			out := reflect.MakeSlice(t, len(s), len(s))
+			for i, item := range s {
+				out.Index(i).SetString(item)
+			}
+			return out, nil
+		}
+	}
+	return rv, fmt.Errorf("cnwfeature: unsupported field type %s for %q", t, key)
+}
+
+// checkFeatureRange enforces the min and max tag options on a decoded value.
+func checkFeatureRange(key string, v reflect.Value, t reflect.Type, opts featureTagOptions) error {
+	if opts.min == nil && opts.max == nil {
+		return nil
+	}
+	var n float64
+	var parse func(string) (float64, error)
+	switch {
+	case t == durationType:
+		n = float64(v.Int())
+		parse = func(s string) (float64, error) {
+			d, err := time.ParseDuration(s)
+			return float64(d), err
+		}
+	case v.CanInt():
+		n = float64(v.Int())
+	case v.CanUint():
+		n = float64(v.Uint())
+	case v.CanFloat():
+		n = v.Float()
+	default:
+		return fmt.Errorf("cnwfeature: min/max not supported for %s (%q)", t, key)
+	}
+	if parse == nil {
+		parse = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	}
+
+	for _, bound := range []struct {
+		raw     *string
+		name    string
+		outside func(n, b float64) bool
+	}{
+		{opts.min, "min", func(n, b float64) bool { return n < b }},
+		{opts.max, "max", func(n, b float64) bool { return n > b }},
+	} {
+		if bound.raw == nil {
+			continue
+		}
+		b, err := parse(*bound.raw)
+		if err != nil {
+			return fmt.Errorf("cnwfeature: invalid %s %q for %q", bound.name, *bound.raw, key)
+		}
+		if bound.outside(n, b) {
+			return fmt.Errorf("%w: %q is %v, %s is %s", ErrFeatureInvalid, key, v.Interface(), bound.name, *bound.raw)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package cnwlicense
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEntitlements struct {
+	MaxUsers  int           `cnwfeature:"max_users,required,min=1"`
+	SSO       bool          `cnwfeature:"sso,default=false"`
+	Tier      string        `cnwfeature:"tier,default=basic"`
+	Ratio     float64       `cnwfeature:"ratio,min=0,max=1"`
+	Retention time.Duration `cnwfeature:"log_retention,default=720h,max=8760h"`
+	Regions   []string      `cnwfeature:"regions"`
+	Seats     uint16        `cnwfeature:"seats"`
+	Limits    struct {
+		RPS int `cnwfeature:"rps,default=100"`
+	} `cnwfeature:"limits"`
+	Ignored  string `cnwfeature:"-"`
+	Untagged string
+}
+
+func decodeJSON(t *testing.T, raw string) Features {
+	t.Helper()
+	var f Features
+	if err := json.Unmarshal([]byte(raw), &f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestFeatures_Decode(t *testing.T) {
+	f := decodeJSON(t, `{
+		"max_users": "250",
+		"sso": "true",
+		"ratio": 0.5,
+		"log_retention": 86400,
+		"regions": ["eu", "us"],
+		"seats": 12,
+		"limits": {"rps": 500},
+		"unrelated": {"x": 1}
+	}`)
+
+	dst := testEntitlements{Ignored: "keep", Untagged: "keep"}
+	if err := f.Decode(&dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dst.MaxUsers != 250 || !dst.SSO || dst.Tier != "basic" || dst.Ratio != 0.5 {
+		t.Errorf("unexpected scalar fields: %+v", dst)
+	}
+	if dst.Retention != 24*time.Hour || dst.Seats != 12 || dst.Limits.RPS != 500 {
+		t.Errorf("unexpected fields: %+v", dst)
+	}
+	if !reflect.DeepEqual(dst.Regions, []string{"eu", "us"}) {
+		t.Errorf("unexpected regions %v", dst.Regions)
+	}
+	if dst.Ignored != "keep" || dst.Untagged != "keep" {
+		t.Error("expected untagged and ignored fields to be left untouched")
+	}
+}
+
+func TestFeatures_Decode_Defaults(t *testing.T) {
+	var dst testEntitlements
+	if err := (Features{"max_users": float64(1), "limits": map[string]interface{}{}}).Decode(&dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dst.SSO || dst.Tier != "basic" || dst.Retention != 720*time.Hour || dst.Limits.RPS != 100 {
+		t.Errorf("expected defaults, got %+v", dst)
+	}
+	if dst.Regions != nil || dst.Ratio != 0 {
+		t.Errorf("expected missing features without defaults to stay zero, got %+v", dst)
+	}
+}
+
+func TestFeatures_Decode_NestedAbsent(t *testing.T) {
+	var dst testEntitlements
+	if err := (Features{"max_users": float64(1)}).Decode(&dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dst.Limits.RPS != 100 {
+		t.Errorf("expected nested default without the object, got %+v", dst.Limits)
+	}
+
+	var required struct {
+		Limits struct {
+			RPS int `cnwfeature:"rps,required"`
+		} `cnwfeature:"limits"`
+	}
+	err := (Features{}).Decode(&required)
+	if !errors.Is(err, ErrFeatureNotFound) || !strings.Contains(err.Error(), `"limits.rps" is required`) {
+		t.Errorf("expected nested required feature error without the object, got %v", err)
+	}
+}
+
+func TestFeatures_Decode_Errors(t *testing.T) {
+	f := decodeJSON(t, `{
+		"sso": "maybe",
+		"ratio": 1.5,
+		"log_retention": "9000h",
+		"seats": -1,
+		"limits": {"rps": "fast"}
+	}`)
+
+	var dst testEntitlements
+	err := f.Decode(&dst)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !errors.Is(err, ErrFeatureNotFound) || !errors.Is(err, ErrFeatureInvalid) {
+		t.Errorf("expected both sentinels, got %v", err)
+	}
+	// Every offending key is named.
+	for _, key := range []string{`"max_users" is required`, `"sso"`, `"ratio"`, `"log_retention"`, `"seats"`, `"limits.rps"`} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected error to mention %s, got:\n%v", key, err)
+		}
+	}
+}
+
+func TestFeatures_Decode_InvalidTarget(t *testing.T) {
+	var notStruct int
+	if err := (Features{}).Decode(&notStruct); err == nil {
+		t.Error("expected error for non-struct target")
+	}
+	if err := (Features{}).Decode(testEntitlements{}); err == nil {
+		t.Error("expected error for non-pointer target")
+	}
+
+	var badTag struct {
+		X int `cnwfeature:"x,between=1"`
+	}
+	if err := (Features{"x": float64(1)}).Decode(&badTag); err == nil || !strings.Contains(err.Error(), "between") {
+		t.Errorf("expected unknown option error, got %v", err)
+	}
+
+	var unsupported struct {
+		X map[string]int `cnwfeature:"x"`
+	}
+	if err := (Features{"x": map[string]interface{}{}}).Decode(&unsupported); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("expected unsupported type error, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package cnwlicense
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeatures_Accessors(t *testing.T) {
+	var f Features
+	if err := json.Unmarshal([]byte(`{
+		"sso": true,
+		"audit": "true",
+		"beta": 0,
+		"max_users": 250,
+		"ratio": 0.75,
+		"max_users_str": " 42 ",
+		"tier": "gold",
+		"retention": "720h",
+		"timeout": 30,
+		"regions": ["eu", "us"],
+		"modules": "billing, reports",
+		"nested": {"a": 1},
+		"empty": null
+	}`), &f); err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.Bool("sso", false) || !f.Bool("audit", false) || f.Bool("beta", true) {
+		t.Error("unexpected Bool results")
+	}
+	if got := f.Int("max_users", 0); got != 250 {
+		t.Errorf("Int(max_users) = %d, want 250", got)
+	}
+	if got := f.Int("max_users_str", 0); got != 42 {
+		t.Errorf("Int(max_users_str) = %d, want 42", got)
+	}
+	if got := f.Float("ratio", 0); got != 0.75 {
+		t.Errorf("Float(ratio) = %v, want 0.75", got)
+	}
+	if got := f.String("tier", ""); got != "gold" {
+		t.Errorf("String(tier) = %q, want gold", got)
+	}
+	if got := f.Duration("retention", 0); got != 720*time.Hour {
+		t.Errorf("Duration(retention) = %v, want 720h", got)
+	}
+	if got := f.Duration("timeout", 0); got != 30*time.Second {
+		t.Errorf("Duration(timeout) = %v, want 30s", got)
+	}
+	if got := f.StringSlice("regions", nil); !reflect.DeepEqual(got, []string{"eu", "us"}) {
+		t.Errorf("StringSlice(regions) = %v", got)
+	}
+	if got := f.StringSlice("modules", nil); !reflect.DeepEqual(got, []string{"billing", "reports"}) {
+		t.Errorf("StringSlice(modules) = %v", got)
+	}
+
+	if !f.Has("sso") || f.Has("missing") || f.Has("empty") {
+		t.Error("unexpected Has results")
+	}
+}
+
+func TestFeatures_Defaults(t *testing.T) {
+	f := Features{"tier": "gold", "nested": map[string]interface{}{"a": float64(1)}}
+
+	if !f.Bool("missing", true) || f.Int("tier", 7) != 7 || f.Float("nested", 1.5) != 1.5 {
+		t.Error("expected defaults for missing or invalid features")
+	}
+	if f.String("nested", "def") != "def" || f.Duration("tier", time.Minute) != time.Minute {
+		t.Error("expected defaults for invalid features")
+	}
+	if got := f.StringSlice("missing", []string{"x"}); !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("expected default slice, got %v", got)
+	}
+
+	// A nil Features map behaves like an empty one.
+	var nilFeatures Features
+	if nilFeatures.Has("x") || nilFeatures.Int("x", 3) != 3 {
+		t.Error("expected nil Features to return defaults")
+	}
+}
+
+func TestFeatures_Errors(t *testing.T) {
+	f := Features{
+		"tier":    "gold",
+		"nested":  map[string]interface{}{"a": float64(1)},
+		"mixed":   []interface{}{"a", float64(1)},
+		"empty":   nil,
+		"flag":    "maybe",
+		"huge":    1e300,
+		"int_max": float64(1 << 63),
+		"nan":     math.NaN(),
+		"nan_str": "NaN",
+		"inf_str": "-Inf",
+		"number":  json.Number("12.5"),
+		"bad_dur": "soon",
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"missing", func() error { _, err := f.IntE("missing"); return err }, ErrFeatureNotFound},
+		{"null", func() error { _, err := f.BoolE("empty"); return err }, ErrFeatureNotFound},
+		{"string as int", func() error { _, err := f.IntE("tier"); return err }, ErrFeatureInvalid},
+		{"object as string", func() error { _, err := f.StringE("nested"); return err }, ErrFeatureInvalid},
+		{"mixed slice", func() error { _, err := f.StringSliceE("mixed"); return err }, ErrFeatureInvalid},
+		{"bad bool", func() error { _, err := f.BoolE("flag"); return err }, ErrFeatureInvalid},
+		{"int overflow", func() error { _, err := f.IntE("huge"); return err }, ErrFeatureInvalid},
+		{"int overflow at 2^63", func() error { _, err := f.IntE("int_max"); return err }, ErrFeatureInvalid},
+		{"NaN as int", func() error { _, err := f.IntE("nan"); return err }, ErrFeatureInvalid},
+		{"NaN string", func() error { _, err := f.FloatE("nan_str"); return err }, ErrFeatureInvalid},
+		{"infinite string as int", func() error { _, err := f.IntE("inf_str"); return err }, ErrFeatureInvalid},
+		{"bad duration", func() error { _, err := f.DurationE("bad_dur"); return err }, ErrFeatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	_, err := f.IntE("tier")
+	if err == nil || !strings.Contains(err.Error(), `"tier"`) {
+		t.Errorf("expected error to name the feature, got %v", err)
+	}
+
+	if n, err := f.FloatE("number"); err != nil || n != 12.5 {
+		t.Errorf("FloatE(json.Number) = %v, %v", n, err)
+	}
+	if n, err := f.IntE("number"); err != nil || n != 12 {
+		t.Errorf("IntE(json.Number) = %v, %v; want truncated 12", n, err)
+	}
+}
+
+func TestFeatures_SharedByResponseTypes(t *testing.T) {
+	var resp ValidateResponse
+	json.Unmarshal([]byte(`{"valid":true,"features":{"max_nodes":"5","sso":"true"}}`), &resp)
+	if resp.Features.Int("max_nodes", 0) != 5 || !resp.Features.Bool("sso", false) {
+		t.Errorf("unexpected features %v", resp.Features)
+	}
+	if got := ExtractHardwareLimits(resp.Features); got.MaxNodes != 5 {
+		t.Errorf("expected MaxNodes 5, got %+v", got)
+	}
+
+	// Plain maps still assign to Features.
+	info := LicenseInfo{Features: map[string]interface{}{"sso": true}}
+	if !info.Features.Bool("sso", false) {
+		t.Error("expected sso=true")
+	}
+}
@@ -24,32 +24,60 @@ func GenerateFingerprint() (string, error) {
 		return fp, nil
 	}
 
-	var parts []string
+	c, err := ReadFingerprintComponents()
+	if err != nil {
+		return "", err
+	}
+	return c.Fingerprint(), nil
+}
+
+// FingerprintComponents are the machine attributes GenerateFingerprint hashes.
+// They are useful for diagnosing why a machine's fingerprint changed.
+type FingerprintComponents struct {
+	Hostname     string   `json:"hostname"`
+	MACAddresses []string `json:"mac_addresses,omitempty"` // sorted, non-loopback
+	OS           string   `json:"os"`
+	Arch         string   `json:"arch"`
+	MachineID    string   `json:"machine_id,omitempty"` // /etc/machine-id, Linux only
+
+	hasMachineID bool // machine-id was readable, even if empty
+}
 
+// ReadFingerprintComponents collects the current machine's fingerprint
+// components. It ignores the CNW_FINGERPRINT override.
+func ReadFingerprintComponents() (*FingerprintComponents, error) {
 	// Hostname
 	hostname, err := os.Hostname()
 	if err != nil {
-		return "", fmt.Errorf("get hostname: %w", err)
+		return nil, fmt.Errorf("get hostname: %w", err)
 	}
-	parts = append(parts, hostname)
+	c := &FingerprintComponents{Hostname: hostname, OS: runtime.GOOS, Arch: runtime.GOARCH}
 
 	// MAC addresses (sorted for determinism, best-effort)
-	macs, err := getMACAddresses()
-	if err == nil && len(macs) > 0 {
-		parts = append(parts, macs...)
+	if macs, err := getMACAddresses(); err == nil {
+		c.MACAddresses = macs
 	}
 
-	// OS and architecture
-	parts = append(parts, runtime.GOOS, runtime.GOARCH)
-
 	// Machine ID (Linux only, best-effort)
 	if machineID, err := os.ReadFile("/etc/machine-id"); err == nil {
-		parts = append(parts, strings.TrimSpace(string(machineID)))
+		c.MachineID = strings.TrimSpace(string(machineID))
+		c.hasMachineID = true
+	}
+	return c, nil
+}
+
+// Fingerprint returns the SHA-256 hex fingerprint of the components.
+func (c *FingerprintComponents) Fingerprint() string {
+	parts := []string{c.Hostname}
+	parts = append(parts, c.MACAddresses...)
+	parts = append(parts, c.OS, c.Arch)
+	if c.hasMachineID || c.MachineID != "" {
+		parts = append(parts, c.MachineID)
 	}
 
 	h := sha256.New()
 	h.Write([]byte(strings.Join(parts, "|")))
-	return fmt.Sprintf("%x", h.Sum(nil)), nil
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
 // getMACAddresses returns sorted, non-loopback hardware MAC addresses.
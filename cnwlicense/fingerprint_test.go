@@ -1,7 +1,10 @@
 package cnwlicense
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
+	"runtime"
 	"testing"
 )
 
@@ -60,3 +63,29 @@ func TestGenerateFingerprint_EnvOverrideEmpty(t *testing.T) {
 		t.Errorf("expected 64 char hex string without env override, got %d chars", len(fp))
 	}
 }
+
+func TestReadFingerprintComponents(t *testing.T) {
+	os.Unsetenv("CNW_FINGERPRINT")
+
+	c, err := ReadFingerprintComponents()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Hostname == "" || c.OS != runtime.GOOS || c.Arch != runtime.GOARCH {
+		t.Errorf("unexpected components: %+v", c)
+	}
+	fp, err := GenerateFingerprint()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.Fingerprint(); got != fp {
+		t.Errorf("components fingerprint %s does not match GenerateFingerprint %s", got, fp)
+	}
+
+	// The hash input is hostname|macs...|os|arch[|machine-id].
+	want := sha256.Sum256([]byte("host|aa:bb|linux|amd64|mid"))
+	c = &FingerprintComponents{Hostname: "host", MACAddresses: []string{"aa:bb"}, OS: "linux", Arch: "amd64", MachineID: "mid"}
+	if got := c.Fingerprint(); got != hex.EncodeToString(want[:]) {
+		t.Errorf("unexpected fingerprint %s", got)
+	}
+}
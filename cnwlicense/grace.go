@@ -0,0 +1,141 @@
+package cnwlicense
+
+import "time"
+
+// LicenseStatus describes the state of a license. StatusValid, StatusExpiring and
+// StatusGrace are states in which the license is accepted.
+type LicenseStatus string
+
+const (
+	// StatusValid means the license is within its validity period.
+	StatusValid LicenseStatus = "valid"
+	// StatusGrace means the license has expired but is still honored during the
+	// post-expiry grace period, possibly with a degraded feature set.
+	StatusGrace LicenseStatus = "grace"
+	// StatusExpiring means the license is valid but expires within the Manager's
+	// expiring threshold (see WithExpiringThreshold).
+	StatusExpiring LicenseStatus = "expiring"
+
+	// StatusUnknown is the Manager's state before the first validation.
+	StatusUnknown LicenseStatus = "unknown"
+	// StatusExpired means the license has expired (and any grace period has ended).
+	StatusExpired LicenseStatus = "expired"
+	// StatusRevoked means the license was revoked, suspended or deactivated.
+	StatusRevoked LicenseStatus = "revoked"
+	// StatusUnreachable means the license server could not be reached and no
+	// cached or offline result could be used instead.
+	StatusUnreachable LicenseStatus = "unreachable"
+	// StatusInvalid means the license was rejected for any other reason: unknown
+	// key, bad signature, wrong machine, hardware limits exceeded, ...
+	StatusInvalid LicenseStatus = "invalid"
+)
+
+// WithExpiryGrace sets a grace period after a license's expires_at during which
+// Verify still accepts the license: it returns the data with Status set to
+// StatusGrace and GraceRemaining set, instead of ErrLicenseExpired. Use
+// EffectiveFeatures to honor the license's degraded feature set. Default: 0 (none).
+func WithExpiryGrace(d time.Duration) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.expiryGrace = d
+	}
+}
+
+// WithManagerExpiryGrace sets a grace period after a license's expiry during which
+// the Manager still reports it as valid, with Status StatusGrace, GraceRemaining
+// set and Features replaced by the license's degraded feature set, if it has one.
+// It applies to cached results, the offline license file and online rejections
+// for expiry: a validate response with valid false and expires_at set, or an
+// ErrLicenseExpired error, whose expiry is then taken from the last-known-good
+// cache (see WithLicenseCache). Without a cache entry, such an error stands.
+// Suspended or revoked licenses are never honored, and a license the server
+// reports as valid is always taken at its word. Default: 0 (none).
+func WithManagerExpiryGrace(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.expiryGrace = d
+	}
+}
+
+// graceRemaining reports whether a license that expires at expiresAt has expired
+// by now but is still within grace, and how much of the grace period remains.
+func graceRemaining(expiresAt, now time.Time, grace time.Duration) (time.Duration, bool) {
+	if expiresAt.IsZero() || !expiresAt.Before(now) {
+		return 0, false
+	}
+	remaining := expiresAt.Add(grace).Sub(now)
+	return remaining, remaining > 0
+}
+
+// EffectiveFeatures returns the features to enforce: the degraded feature set
+// while the license is in its grace period (if the license defines one),
+// otherwise Features.
+func (d *OfflineLicenseData) EffectiveFeatures() Features {
+	if d.Status == StatusGrace && d.DegradedFeatures != nil {
+		return d.DegradedFeatures
+	}
+	return d.Features
+}
+
+// responseInfo converts a validation response into a LicenseInfo. Returns nil if
+// the response does not describe a usable license.
+//
+// A license the server rejected for expiry, or a cached result whose license has
+// since expired, is reported as valid with StatusGrace and its degraded features
+// while it expired less than the expiry grace period ago. A license the server
+// reports as valid is taken at its word, whatever the local clock says.
+func (m *Manager) responseInfo(licenseKey, fingerprint string, resp *ValidateResponse, source LicenseSource) *LicenseInfo {
+	now := m.clock.Now()
+	info := &LicenseInfo{
+		Valid:       true,
+		LicenseKey:  licenseKey,
+		Plan:        resp.Plan,
+		Features:    resp.Features,
+		ExpiresAt:   resp.ExpiresAt,
+		Fingerprint: fingerprint,
+		Source:      source,
+		Status:      StatusValid,
+	}
+	exp := resp.ExpiresAt
+	var remaining time.Duration
+	switch {
+	case !resp.Valid:
+		// Only an expiry rejection can be honored; the server's clock decides
+		// that the license has expired.
+		if m.expiryGrace <= 0 || exp == nil || m.rejectedStatus(resp) != StatusExpired {
+			return nil
+		}
+		if remaining = exp.Add(m.expiryGrace).Sub(now); remaining <= 0 {
+			return nil
+		}
+	case source == SourceOnline || exp == nil || !exp.Before(now):
+		return info
+	default:
+		var ok bool
+		if remaining, ok = graceRemaining(*exp, now, m.expiryGrace); !ok {
+			return nil
+		}
+	}
+
+	info.Status = StatusGrace
+	info.GraceRemaining = min(remaining, m.expiryGrace)
+	if resp.DegradedFeatures != nil {
+		info.Features = resp.DegradedFeatures
+	}
+	return info
+}
+
+// expiredGrace answers an ErrLicenseExpired rejection from the server during
+// the expiry grace period. The error carries no expires_at, so the expiry and
+// features are taken from the last-known-good cache entry. Returns nil if there
+// is no usable entry or the grace period has ended.
+func (m *Manager) expiredGrace(licenseKey, fingerprint string) *LicenseInfo {
+	if m.expiryGrace <= 0 || m.cache == nil {
+		return nil
+	}
+	entry, err := m.loadCache(licenseKey, fingerprint)
+	if err != nil {
+		return nil
+	}
+	resp := entry.Response
+	resp.Valid, resp.Reason = false, "license expired"
+	return m.responseInfo(licenseKey, fingerprint, &resp, SourceOnline)
+}
@@ -0,0 +1,279 @@
+package cnwlicense_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+func TestOfflineValidator_ExpiryGrace(t *testing.T) {
+	s := newSigner(t)
+	file := signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey:       "CNW-GRACE",
+		Plan:             "enterprise",
+		Features:         map[string]interface{}{"max_nodes": float64(10)},
+		DegradedFeatures: map[string]interface{}{"max_nodes": float64(2)},
+		ExpiresAt:        time.Now().Add(-time.Hour),
+		IssuedAt:         time.Now().Add(-365 * 24 * time.Hour),
+	})
+
+	// Without a grace period, an expired license is rejected.
+	if _, err := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey())).Verify(file); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Fatalf("expected ErrLicenseExpired, got %v", err)
+	}
+
+	data, err := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithExpiryGrace(24*time.Hour)).Verify(file)
+	if err != nil {
+		t.Fatalf("expected license within grace to verify, got %v", err)
+	}
+	if data.Status != cnwlicense.StatusGrace {
+		t.Errorf("expected status grace, got %s", data.Status)
+	}
+	if data.GraceRemaining <= 22*time.Hour || data.GraceRemaining > 23*time.Hour {
+		t.Errorf("expected ~23h grace remaining, got %v", data.GraceRemaining)
+	}
+	if got := data.EffectiveFeatures()["max_nodes"]; got != float64(2) {
+		t.Errorf("expected degraded max_nodes=2, got %v", got)
+	}
+
+	// Past the grace period, the license is rejected again.
+	if _, err := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithExpiryGrace(30*time.Minute)).Verify(file); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired after grace, got %v", err)
+	}
+}
+
+func TestOfflineValidator_StatusValid(t *testing.T) {
+	s := newSigner(t)
+	data, err := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey())).Verify(
+		signLicense(t, s, cnwlicense.OfflineLicenseData{
+			LicenseKey:       "CNW-GRACE",
+			Features:         map[string]interface{}{"max_nodes": float64(10)},
+			DegradedFeatures: map[string]interface{}{"max_nodes": float64(2)},
+			ExpiresAt:        time.Now().Add(time.Hour),
+			IssuedAt:         time.Now(),
+		}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.Status != cnwlicense.StatusValid || data.GraceRemaining != 0 {
+		t.Errorf("expected status valid without grace, got %s, %v", data.Status, data.GraceRemaining)
+	}
+	if got := data.EffectiveFeatures()["max_nodes"]; got != float64(10) {
+		t.Errorf("expected full max_nodes=10 outside grace, got %v", got)
+	}
+}
+
+func TestManager_ExpiryGrace_Online(t *testing.T) {
+	expired := time.Now().Add(-2 * time.Hour).UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cnwlicense.ValidateResponse{
+			Valid:            false,
+			Reason:           "license expired",
+			Plan:             "enterprise",
+			ExpiresAt:        &expired,
+			Features:         map[string]interface{}{"max_nodes": float64(10), "sso": true},
+			DegradedFeatures: map[string]interface{}{"max_nodes": float64(2)},
+		})
+	}))
+	defer server.Close()
+	client := cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))
+
+	info, err := cnwlicense.NewManager(cnwlicense.WithOnlineClient(client)).ValidateAndEnforce(context.Background(), "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Valid {
+		t.Error("expected expired license to be invalid without a grace period")
+	}
+
+	info, err = cnwlicense.NewManager(cnwlicense.WithOnlineClient(client), cnwlicense.WithManagerExpiryGrace(7*24*time.Hour)).
+		ValidateAndEnforce(context.Background(), "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Valid || info.Status != cnwlicense.StatusGrace {
+		t.Fatalf("expected valid license in grace, got valid=%v status=%s", info.Valid, info.Status)
+	}
+	if info.GraceRemaining <= 0 || info.GraceRemaining > 7*24*time.Hour {
+		t.Errorf("unexpected grace remaining %v", info.GraceRemaining)
+	}
+	if _, ok := info.Features["sso"]; ok || info.Features["max_nodes"] != float64(2) {
+		t.Errorf("expected degraded features, got %v", info.Features)
+	}
+	if info.Plan != "enterprise" {
+		t.Errorf("expected plan enterprise, got %s", info.Plan)
+	}
+}
+
+func TestManager_ExpiryGrace_OnlineRejections(t *testing.T) {
+	soon := time.Now().Add(-time.Hour).UTC()
+	var resp atomic.Pointer[cnwlicense.ValidateResponse]
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp.Load())
+	}))
+	defer server.Close()
+	client := cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))
+	ctx := context.Background()
+
+	// A suspended license is never honored, even within the grace window.
+	resp.Store(&cnwlicense.ValidateResponse{Valid: false, Reason: "license is suspended", ExpiresAt: &soon})
+	info, err := cnwlicense.NewManager(cnwlicense.WithOnlineClient(client), cnwlicense.WithManagerExpiryGrace(7*24*time.Hour)).ValidateAndEnforce(ctx, "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Valid || info.Status != cnwlicense.StatusRevoked {
+		t.Errorf("expected suspended license to be rejected, got valid=%v status=%s", info.Valid, info.Status)
+	}
+
+	// Without a grace period, a license the server reports as valid is trusted
+	// even if it has expired by the local clock.
+	resp.Store(&cnwlicense.ValidateResponse{Valid: true, Plan: "enterprise", ExpiresAt: &soon})
+	info, err = cnwlicense.NewManager(cnwlicense.WithOnlineClient(client)).ValidateAndEnforce(ctx, "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Valid || info.Status != cnwlicense.StatusValid {
+		t.Errorf("expected server verdict to stand, got valid=%v status=%s", info.Valid, info.Status)
+	}
+}
+
+func TestManager_ExpiryGrace_ForbiddenUsesCache(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(time.Now())
+	expires := clock.Now().Add(time.Hour).UTC()
+	var expired atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if expired.Load() {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":"FORBIDDEN","message":"license expired"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(cnwlicense.ValidateResponse{
+			Valid:            true,
+			Plan:             "enterprise",
+			ExpiresAt:        &expires,
+			Features:         map[string]interface{}{"max_nodes": float64(10)},
+			DegradedFeatures: map[string]interface{}{"max_nodes": float64(2)},
+		})
+	}))
+	defer server.Close()
+
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithLicenseCache(cnwlicense.NewFileCacheStore(t.TempDir()), 72*time.Hour),
+		cnwlicense.WithCacheSecret([]byte("app-secret")),
+		cnwlicense.WithManagerExpiryGrace(24*time.Hour),
+		cnwlicense.WithManagerClock(clock),
+	)
+	ctx := context.Background()
+	if _, err := mgr.ValidateAndEnforce(ctx, "CNW-GRACE"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expired.Store(true)
+	clock.Advance(2 * time.Hour)
+	info, err := mgr.ValidateAndEnforce(ctx, "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("expected expiry rejection within grace to be honored, got %v", err)
+	}
+	if info.Status != cnwlicense.StatusGrace || info.Source != cnwlicense.SourceOnline || info.Features["max_nodes"] != float64(2) {
+		t.Errorf("expected degraded license in grace, got source=%s status=%s features=%v", info.Source, info.Status, info.Features)
+	}
+
+	clock.Advance(24 * time.Hour)
+	if _, err := mgr.ValidateAndEnforce(ctx, "CNW-GRACE"); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired after grace, got %v", err)
+	}
+}
+
+func TestManager_ExpiryGrace_OfflineFile(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-GRACE",
+		Plan:       "enterprise",
+		Features:   map[string]interface{}{"max_nodes": float64(10)},
+		ExpiresAt:  time.Now().Add(-time.Hour),
+		IssuedAt:   time.Now().Add(-24 * time.Hour),
+	})
+	newManager := func(opts ...cnwlicense.ManagerOption) *cnwlicense.Manager {
+		return cnwlicense.NewManager(append([]cnwlicense.ManagerOption{
+			cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+			cnwlicense.WithOfflineLicenseFile(path),
+		}, opts...)...)
+	}
+
+	if _, err := newManager().ValidateOffline("CNW-GRACE"); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Fatalf("expected ErrLicenseExpired, got %v", err)
+	}
+
+	info, err := newManager(cnwlicense.WithManagerExpiryGrace(24 * time.Hour)).ValidateOffline("CNW-GRACE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != cnwlicense.StatusGrace || info.Source != cnwlicense.SourceOffline {
+		t.Errorf("expected offline license in grace, got status=%s source=%s", info.Status, info.Source)
+	}
+	// Without degraded features, the full feature set stays in effect.
+	if info.Features["max_nodes"] != float64(10) {
+		t.Errorf("expected full features, got %v", info.Features)
+	}
+}
+
+func TestManager_ExpiryGrace_Cache(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(time.Now())
+	expires := clock.Now().Add(time.Hour).UTC()
+	var down atomic.Bool
+	resp := &cnwlicense.ValidateResponse{
+		Valid:            true,
+		Plan:             "enterprise",
+		ExpiresAt:        &expires,
+		Features:         map[string]interface{}{"max_nodes": float64(10)},
+		DegradedFeatures: map[string]interface{}{"max_nodes": float64(2)},
+	}
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		if down.Load() {
+			return nil
+		}
+		return resp
+	})
+
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithLicenseCache(cnwlicense.NewFileCacheStore(t.TempDir()), 72*time.Hour),
+		cnwlicense.WithCacheSecret([]byte("app-secret")),
+		cnwlicense.WithManagerExpiryGrace(24*time.Hour),
+		cnwlicense.WithManagerClock(clock),
+	)
+	ctx := context.Background()
+	info, err := mgr.ValidateAndEnforce(ctx, "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Status != cnwlicense.StatusValid {
+		t.Errorf("expected status valid, got %s", info.Status)
+	}
+
+	down.Store(true)
+	clock.Advance(2 * time.Hour)
+	info, err = mgr.ValidateAndEnforce(ctx, "CNW-GRACE")
+	if err != nil {
+		t.Fatalf("expected cached license in grace, got %v", err)
+	}
+	if info.Source != cnwlicense.SourceCache || info.Status != cnwlicense.StatusGrace || info.Features["max_nodes"] != float64(2) {
+		t.Errorf("expected degraded cached license, got source=%s status=%s features=%v", info.Source, info.Status, info.Features)
+	}
+
+	clock.Advance(24 * time.Hour)
+	if _, err := mgr.ValidateAndEnforce(ctx, "CNW-GRACE"); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired after grace, got %v", err)
+	}
+}
@@ -6,20 +6,13 @@ import (
 )
 
 // ExtractHardwareLimits extracts hardware limits from a license features map.
-// JSON numbers are float64 by default, so this handles the conversion.
-// A value of 0 means unlimited.
-func ExtractHardwareLimits(features map[string]interface{}) HardwareLimits {
-	var limits HardwareLimits
-	if features == nil {
-		return limits
+// Values may be any number, json.Number or numeric string (see Features.Int).
+// A value of 0, or a missing or unparseable one, means unlimited.
+func ExtractHardwareLimits(features Features) HardwareLimits {
+	return HardwareLimits{
+		MaxCPUPerNode: features.Int("max_cpu_per_node", 0),
+		MaxNodes:      features.Int("max_nodes", 0),
 	}
-	if v, ok := features["max_cpu_per_node"]; ok {
-		limits.MaxCPUPerNode = toInt(v)
-	}
-	if v, ok := features["max_nodes"]; ok {
-		limits.MaxNodes = toInt(v)
-	}
-	return limits
 }
 
 // CheckCPU verifies that the current machine's CPU count does not exceed the limit.
@@ -46,17 +39,3 @@ func CheckNodeCount(limits HardwareLimits, currentNodes int) error {
 	}
 	return nil
 }
-
-// toInt converts a JSON number (float64) or integer to int.
-func toInt(v interface{}) int {
-	switch n := v.(type) {
-	case float64:
-		return int(n)
-	case int:
-		return n
-	case int64:
-		return int(n)
-	default:
-		return 0
-	}
-}
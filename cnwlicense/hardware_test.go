@@ -1,6 +1,7 @@
 package cnwlicense
 
 import (
+	"encoding/json"
 	"errors"
 	"runtime"
 	"testing"
@@ -52,6 +53,22 @@ func TestExtractHardwareLimits(t *testing.T) {
 			},
 			want: HardwareLimits{MaxCPUPerNode: 0, MaxNodes: 10},
 		},
+		{
+			name: "json.Number and string values",
+			features: map[string]interface{}{
+				"max_cpu_per_node": json.Number("12"),
+				"max_nodes":        "4",
+			},
+			want: HardwareLimits{MaxCPUPerNode: 12, MaxNodes: 4},
+		},
+		{
+			name: "non-numeric values are unlimited",
+			features: map[string]interface{}{
+				"max_cpu_per_node": "many",
+				"max_nodes":        map[string]interface{}{"soft": float64(3)},
+			},
+			want: HardwareLimits{},
+		},
 		{
 			name: "unrelated features ignored",
 			features: map[string]interface{}{
@@ -0,0 +1,99 @@
+package cnwlicense
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHeartbeatInterval = 5 * time.Minute
+	defaultHeartbeatJitter   = 0.1
+)
+
+// WithHeartbeatInterval sets how often StartHeartbeat checks in with the server.
+// Default is 5 minutes.
+func WithHeartbeatInterval(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.heartbeatInterval = d
+	}
+}
+
+// WithHeartbeatJitter sets the fraction (0..1) by which each heartbeat interval is
+// randomly shortened or lengthened, so a fleet does not check in at the same instant.
+// Default is 0.1 (±10%).
+func WithHeartbeatJitter(fraction float64) ManagerOption {
+	return func(m *Manager) {
+		m.heartbeatJitter = fraction
+	}
+}
+
+// WithHeartbeatErrorHandler sets a callback invoked (from the heartbeat goroutine)
+// whenever a heartbeat fails. Failures never stop the loop.
+func WithHeartbeatErrorHandler(fn func(error)) ManagerOption {
+	return func(m *Manager) {
+		m.onHeartbeatError = fn
+	}
+}
+
+// StartHeartbeat starts a background loop that periodically calls Heartbeat for
+// this machine's activation, keeping its LastSeenAt fresh. The fingerprint is
+// resolved the same way as in ActivateNode. The first heartbeat is sent immediately.
+//
+// The loop runs until ctx is canceled or the returned stop function is called;
+// stop blocks until the loop has exited and is safe to call more than once.
+func (m *Manager) StartHeartbeat(ctx context.Context, licenseKey string) (stop func(), err error) {
+	if m.client == nil {
+		return nil, fmt.Errorf("online client is required for StartHeartbeat")
+	}
+	if m.heartbeatInterval <= 0 {
+		return nil, fmt.Errorf("heartbeat interval must be positive, got %v", m.heartbeatInterval)
+	}
+
+	fingerprint, err := m.resolveFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("resolve fingerprint: %w", err)
+	}
+	req := HeartbeatRequest{
+		LicenseKey:  licenseKey,
+		Fingerprint: fingerprint,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, err := m.client.Heartbeat(ctx, req); err != nil && ctx.Err() == nil && m.onHeartbeatError != nil {
+				m.onHeartbeatError(err)
+			}
+
+			t := time.NewTimer(m.nextHeartbeat())
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}, nil
+}
+
+// nextHeartbeat returns the heartbeat interval with jitter applied.
+func (m *Manager) nextHeartbeat() time.Duration {
+	d := m.heartbeatInterval
+	if j := min(max(m.heartbeatJitter, 0), 1); j > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * j * float64(d))
+	}
+	return d
+}
@@ -0,0 +1,99 @@
+package cnwlicense
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManager_StartHeartbeat(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req HeartbeatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Fingerprint != "node-fp" {
+			t.Errorf("expected fingerprint node-fp, got %s", req.Fingerprint)
+		}
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": HeartbeatResponse{ID: "act-001", LastSeenAt: time.Now()},
+		})
+	}))
+	defer server.Close()
+
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithHeartbeatInterval(10*time.Millisecond),
+		WithHeartbeatErrorHandler(func(err error) { t.Errorf("unexpected heartbeat error: %v", err) }),
+	)
+	stop, err := mgr.StartHeartbeat(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	stop()
+	stop() // safe to call twice
+
+	// Let any request canceled mid-flight reach the handler before counting.
+	time.Sleep(20 * time.Millisecond)
+	n := atomic.LoadInt32(&calls)
+	if n < 3 {
+		t.Errorf("expected several heartbeats, got %d", n)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after := atomic.LoadInt32(&calls); after != n {
+		t.Errorf("expected no heartbeats after stop, got %d more", after-n)
+	}
+}
+
+func TestManager_StartHeartbeat_ReportsErrorsAndStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	errs := make(chan error, 100)
+	mgr := NewManager(
+		WithOnlineClient(NewOnlineClient(server.URL, "test-key", WithFingerprint("node-fp"))),
+		WithHeartbeatInterval(10*time.Millisecond),
+		WithHeartbeatJitter(0.5),
+		WithHeartbeatErrorHandler(func(err error) { errs <- err }),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	stop, err := mgr.StartHeartbeat(ctx, "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer stop()
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("expected non-nil heartbeat error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected heartbeat error to be reported")
+	}
+
+	// A failing heartbeat does not stop the loop.
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Fatal("expected loop to keep running after a failure")
+	}
+
+	cancel()
+	stop() // returns once the loop has exited
+}
+
+func TestManager_StartHeartbeat_RequiresClient(t *testing.T) {
+	mgr := NewManager()
+	if _, err := mgr.StartHeartbeat(context.Background(), "CNW-TEST-1234"); err == nil {
+		t.Fatal("expected error without online client")
+	}
+}
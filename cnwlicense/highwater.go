@@ -0,0 +1,121 @@
+package cnwlicense
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// defaultRollbackTolerance is how far the clock may fall behind the high-water
+// mark (NTP corrections, issuer clock drift) before ErrClockTampered is returned.
+const defaultRollbackTolerance = time.Hour
+
+// HighWaterMarkStore persists the latest time an OfflineValidator has observed,
+// so that setting the system clock back can be detected across restarts.
+type HighWaterMarkStore interface {
+	// Load returns the stored high-water mark, or the zero time if there is none.
+	Load() (time.Time, error)
+	// Save replaces the stored high-water mark.
+	Save(t time.Time) error
+}
+
+// FileHighWaterMarkStore is a HighWaterMarkStore backed by a single HMAC-protected file.
+// With an application secret, editing the file is detected (ErrClockTampered);
+// deleting it resets the mark, so keep it somewhere the application, not the end
+// user, controls.
+type FileHighWaterMarkStore struct {
+	path string
+	key  []byte
+}
+
+// hwmEntry is the payload persisted by FileHighWaterMarkStore.
+type hwmEntry struct {
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// NewFileHighWaterMarkStore creates a file-based high-water mark store at path.
+// secret is the HMAC key protecting the file. If empty, a built-in key is used;
+// that key is public (it ships with this SDK), so anyone can forge a valid file
+// and the default only detects corruption. Embed an application secret to make
+// the file tamper-evident.
+func NewFileHighWaterMarkStore(path string, secret []byte) *FileHighWaterMarkStore {
+	key := secret
+	if len(key) == 0 {
+		key = deriveKey("clock")
+	}
+	return &FileHighWaterMarkStore{path: path, key: key}
+}
+
+// Load reads and verifies the high-water mark file.
+func (s *FileHighWaterMarkStore) Load() (time.Time, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("read high-water mark file: %w", err)
+	}
+	var entry hwmEntry
+	if err := openJSON(s.key, raw, &entry, ErrClockTampered); err != nil {
+		return time.Time{}, err
+	}
+	return entry.ObservedAt, nil
+}
+
+// Save atomically writes the high-water mark file with 0600 permissions.
+func (s *FileHighWaterMarkStore) Save(t time.Time) error {
+	raw, err := sealJSON(s.key, hwmEntry{ObservedAt: t.UTC()})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, raw)
+}
+
+// WithClockRollbackDetection makes the validator record the latest time it has
+// observed in store and reject verification with ErrClockTampered when the
+// current time is more than tolerance behind it. Every license whose signature
+// verifies raises the mark to the later of the current time and its issued_at,
+// even if it is then rejected as expired or revoked, so a clock set before a
+// license was issued is detected on first use. While the clock is within
+// tolerance of the mark, the mark is used as "now" for expiry and revocation
+// checks. A tolerance <= 0 uses the default of 1h.
+func WithClockRollbackDetection(store HighWaterMarkStore, tolerance time.Duration) OfflineOption {
+	return func(v *OfflineValidator) {
+		if tolerance <= 0 {
+			tolerance = defaultRollbackTolerance
+		}
+		v.hwmStore = store
+		v.hwmTolerance = tolerance
+	}
+}
+
+// observeTime raises the high-water mark to the later of now and issuedAt,
+// checks now against it and returns the time to use for expiry and revocation
+// checks. Failing to save the mark is ignored so that a read-only disk never
+// fails verification.
+func (v *OfflineValidator) observeTime(now, issuedAt time.Time) (time.Time, error) {
+	v.hwmMu.Lock()
+	defer v.hwmMu.Unlock()
+
+	stored, err := v.hwmStore.Load()
+	if err != nil {
+		return now, fmt.Errorf("load clock high-water mark: %w", err)
+	}
+	mark := stored
+	if issuedAt.After(mark) {
+		mark = issuedAt
+	}
+	if now.After(mark) {
+		mark = now
+	}
+	if !mark.Equal(stored) {
+		_ = v.hwmStore.Save(mark)
+	}
+
+	if now.Add(v.hwmTolerance).Before(mark) {
+		return now, fmt.Errorf("%w: clock is %s behind the last observed time %s",
+			ErrClockTampered, mark.Sub(now).Round(time.Second), mark.Format(time.RFC3339))
+	}
+	return mark, nil
+}
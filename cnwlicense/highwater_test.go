@@ -0,0 +1,204 @@
+package cnwlicense_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+// memoryHighWaterMarkStore is an in-memory HighWaterMarkStore for tests.
+type memoryHighWaterMarkStore struct {
+	mark time.Time
+}
+
+func (s *memoryHighWaterMarkStore) Load() (time.Time, error) { return s.mark, nil }
+func (s *memoryHighWaterMarkStore) Save(t time.Time) error   { s.mark = t; return nil }
+
+func TestOfflineValidator_ClockRollback(t *testing.T) {
+	s := newSigner(t)
+	file := signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		Plan:       "enterprise",
+		ExpiresAt:  time.Now().Add(30 * 24 * time.Hour),
+		IssuedAt:   time.Now().Add(-24 * time.Hour),
+	})
+
+	store := &memoryHighWaterMarkStore{}
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithClockRollbackDetection(store, time.Hour))
+
+	if _, err := v.Verify(file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Since(store.mark) > time.Minute {
+		t.Errorf("expected mark to record the current time, got %v", store.mark)
+	}
+
+	// Simulate a clock that was previously observed two days ahead of "now".
+	store.mark = time.Now().Add(48 * time.Hour)
+	data, err := v.Verify(file)
+	if !errors.Is(err, cnwlicense.ErrClockTampered) {
+		t.Fatalf("expected ErrClockTampered, got %v", err)
+	}
+	if data == nil || data.Plan != "enterprise" {
+		t.Errorf("expected data alongside error, got %+v", data)
+	}
+}
+
+func TestOfflineValidator_ClockRollback_WithinTolerance(t *testing.T) {
+	s := newSigner(t)
+	file := signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		ExpiresAt:  time.Now().Add(10 * time.Minute),
+		IssuedAt:   time.Now().Add(-24 * time.Hour),
+	})
+
+	// The mark is 30 minutes ahead: within tolerance, but past the license's
+	// expiry, so the mark rather than the wall clock decides expiry.
+	store := &memoryHighWaterMarkStore{mark: time.Now().Add(30 * time.Minute)}
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithClockRollbackDetection(store, time.Hour),
+	)
+	if _, err := v.Verify(file); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired, got %v", err)
+	}
+}
+
+func TestOfflineValidator_ClockRollback_IssuedAtLowerBound(t *testing.T) {
+	s := newSigner(t)
+	store := &memoryHighWaterMarkStore{}
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithClockRollbackDetection(store, time.Hour),
+	)
+
+	// Within the rollback tolerance, the issued_at skew check still applies.
+	_, err := v.Verify(signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		ExpiresAt:  time.Now().Add(30 * 24 * time.Hour),
+		IssuedAt:   time.Now().Add(30 * time.Minute),
+	}))
+	if !errors.Is(err, cnwlicense.ErrLicenseIssuedInFuture) {
+		t.Fatalf("expected ErrLicenseIssuedInFuture within tolerance, got %v", err)
+	}
+
+	// A license issued a week from "now" means this clock is behind.
+	issuedAt := time.Now().Add(7 * 24 * time.Hour)
+	_, err = v.Verify(signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		ExpiresAt:  time.Now().Add(30 * 24 * time.Hour),
+		IssuedAt:   issuedAt,
+	}))
+	if !errors.Is(err, cnwlicense.ErrClockTampered) {
+		t.Fatalf("expected ErrClockTampered, got %v", err)
+	}
+	if !store.mark.Equal(issuedAt) {
+		t.Errorf("expected issued_at to raise the mark to %v, got %v", issuedAt, store.mark)
+	}
+}
+
+func TestOfflineValidator_ClockRollback_AfterExpiry(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock := cnwlicensetest.NewFakeClock(start)
+	s := newSigner(t)
+	file := signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		ExpiresAt:  start.Add(-30 * 24 * time.Hour),
+		IssuedAt:   start.Add(-365 * 24 * time.Hour),
+	})
+	store := &memoryHighWaterMarkStore{}
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithValidatorClock(clock),
+		cnwlicense.WithClockRollbackDetection(store, time.Hour),
+	)
+
+	// A rejected license still raises the mark, so winding the clock back to
+	// before its expiry is detected.
+	if _, err := v.Verify(file); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Fatalf("expected ErrLicenseExpired, got %v", err)
+	}
+	if !store.mark.Equal(start) {
+		t.Errorf("expected a rejected license to raise the mark to %v, got %v", start, store.mark)
+	}
+	clock.Set(start.Add(-31 * 24 * time.Hour))
+	if _, err := v.Verify(file); !errors.Is(err, cnwlicense.ErrClockTampered) {
+		t.Errorf("expected ErrClockTampered after setting the clock back, got %v", err)
+	}
+}
+
+func TestOfflineValidator_ClockRollback_BadSignatureKeepsMark(t *testing.T) {
+	s := newSigner(t)
+	mark := time.Now().Add(-24 * time.Hour)
+	store := &memoryHighWaterMarkStore{mark: mark}
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(newSigner(t).PublicKey()),
+		cnwlicense.WithClockRollbackDetection(store, time.Hour),
+	)
+
+	_, err := v.Verify(signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		ExpiresAt:  time.Now().Add(30 * 24 * time.Hour),
+		IssuedAt:   time.Now().Add(7 * 24 * time.Hour),
+	}))
+	if !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
+		t.Fatalf("expected ErrSignatureInvalid, got %v", err)
+	}
+	if !store.mark.Equal(mark) {
+		t.Errorf("expected an unverified file to keep the mark at %v, got %v", mark, store.mark)
+	}
+}
+
+func TestFileHighWaterMarkStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clock", "hwm.json")
+	store := cnwlicense.NewFileHighWaterMarkStore(path, []byte("app-secret"))
+
+	mark, err := store.Load()
+	if err != nil || !mark.IsZero() {
+		t.Fatalf("expected zero mark for missing file, got %v, %v", mark, err)
+	}
+
+	want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.Save(want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if mark, err := store.Load(); err != nil || !mark.Equal(want) {
+		t.Errorf("expected %v, got %v, %v", want, mark, err)
+	}
+
+	// A different secret cannot read the file.
+	if _, err := cnwlicense.NewFileHighWaterMarkStore(path, []byte("other")).Load(); !errors.Is(err, cnwlicense.ErrClockTampered) {
+		t.Errorf("expected ErrClockTampered for wrong secret, got %v", err)
+	}
+
+	// Editing the file is detected.
+	raw, _ := os.ReadFile(path)
+	os.WriteFile(path, append(raw[:len(raw)-10], []byte(`AAAAAAA"}`)...), 0600)
+	if _, err := store.Load(); !errors.Is(err, cnwlicense.ErrClockTampered) {
+		t.Errorf("expected ErrClockTampered for edited file, got %v", err)
+	}
+}
+
+func TestOfflineValidator_ClockRollback_TamperedStoreFailsClosed(t *testing.T) {
+	s := newSigner(t)
+	path := filepath.Join(t.TempDir(), "hwm.json")
+	os.WriteFile(path, []byte(`{"payload":{"observed_at":"2020-01-01T00:00:00Z"},"mac":"AAAA"}`), 0600)
+
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithClockRollbackDetection(cnwlicense.NewFileHighWaterMarkStore(path, nil), 0),
+	)
+	_, err := v.Verify(signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-CLOCK",
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		IssuedAt:   time.Now(),
+	}))
+	if !errors.Is(err, cnwlicense.ErrClockTampered) {
+		t.Errorf("expected ErrClockTampered, got %v", err)
+	}
+}
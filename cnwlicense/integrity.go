@@ -0,0 +1,66 @@
+package cnwlicense
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// sealedEnvelope is the on-disk format for locally persisted state (cache entries,
+// clock high-water marks). The payload is kept as raw JSON so the MAC is computed
+// over the exact bytes, the same way offline license signatures are.
+type sealedEnvelope struct {
+	Payload json.RawMessage `json:"payload"`
+	MAC     string          `json:"mac"`
+}
+
+// sealJSON marshals v and wraps it in an HMAC-SHA256 protected envelope.
+func sealJSON(key []byte, v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+	return json.Marshal(sealedEnvelope{
+		Payload: payload,
+		MAC:     base64.StdEncoding.EncodeToString(computeMAC(key, payload)),
+	})
+}
+
+// openJSON verifies an envelope produced by sealJSON and decodes its payload into v.
+// Returns an error wrapping tamperErr if the envelope is malformed or the MAC does not match.
+func openJSON(key, raw []byte, v interface{}, tamperErr error) error {
+	var env sealedEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("%w: %v", tamperErr, err)
+	}
+	mac, err := base64.StdEncoding.DecodeString(env.MAC)
+	if err != nil || len(env.Payload) == 0 {
+		return fmt.Errorf("%w: malformed envelope", tamperErr)
+	}
+	if !hmac.Equal(mac, computeMAC(key, env.Payload)) {
+		return fmt.Errorf("%w: MAC mismatch", tamperErr)
+	}
+	if err := json.Unmarshal(env.Payload, v); err != nil {
+		return fmt.Errorf("%w: %v", tamperErr, err)
+	}
+	return nil
+}
+
+func computeMAC(key, payload []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// deriveKey derives a 32-byte key from a purpose label and context parts.
+// Used when the application does not supply its own secret.
+func deriveKey(purpose string, parts ...string) []byte {
+	h := sha256.New()
+	h.Write([]byte("cnw-license-sdk|" + purpose))
+	for _, p := range parts {
+		h.Write([]byte("|" + p))
+	}
+	return h.Sum(nil)
+}
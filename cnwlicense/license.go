@@ -2,7 +2,10 @@ package cnwlicense
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 )
 
 // Manager is the top-level orchestrator that combines online/offline validation
@@ -10,6 +13,34 @@ import (
 type Manager struct {
 	client  *OnlineClient
 	offline *OfflineValidator
+
+	offlineFile     string // signed license file used for offline validation
+	offlineFallback bool   // fall back to offlineFile when the server is unreachable
+	anyOfflineKey   bool   // accept offlineFile for any license key when none is requested
+
+	cache       CacheStore    // last-known-good validation results
+	cacheGrace  time.Duration // how long a cached result is honored
+	cacheSecret []byte        // HMAC key for cache entries (derived if empty)
+
+	heartbeatInterval time.Duration
+	heartbeatJitter   float64
+	onHeartbeatError  func(error)
+
+	clock       Clock
+	expiryGrace time.Duration // post-expiry grace period
+
+	expiringThreshold time.Duration   // report StatusExpiring this long before expiry
+	expiryWarnings    []time.Duration // warning thresholds, largest first
+	onExpiryWarning   func(ExpiryWarning)
+
+	notifyMu    sync.Mutex // serializes state updates with their notifications
+	stateMu     sync.Mutex
+	state       LicenseStatus
+	current     *LicenseInfo
+	subscribers []*subscriber
+
+	warnedExpiresAt time.Time // expiry the warnings below refer to
+	warnedLevel     int       // number of expiryWarnings already reported
 }
 
 // ManagerOption configures a Manager.
@@ -29,9 +60,52 @@ func WithOfflineValidator(v *OfflineValidator) ManagerOption {
 	}
 }
 
+// WithOfflineLicenseFile sets the path of the signed license file the Manager
+// verifies with its OfflineValidator.
+func WithOfflineLicenseFile(path string) ManagerOption {
+	return func(m *Manager) {
+		m.offlineFile = path
+	}
+}
+
+// WithOfflineFallback makes ValidateAndEnforce fall back to the offline license
+// file (see WithOfflineLicenseFile) when the license server cannot be reached:
+// network errors, timeouts, throttling and 502/503/504 responses.
+// Explicit rejections from the server (invalid, expired, not found, ...) never
+// trigger the fallback. Requires WithOfflineValidator and WithOfflineLicenseFile.
+func WithOfflineFallback(enabled bool) ManagerOption {
+	return func(m *Manager) {
+		m.offlineFallback = enabled
+	}
+}
+
+// WithAnyOfflineLicenseKey makes ValidateOffline accept the offline license file
+// whatever license key it carries when called with an empty license key. Without
+// it, an empty key is rejected with ErrLicenseKeyMismatch, since any validly
+// signed file (including one issued to another customer) would otherwise pass.
+func WithAnyOfflineLicenseKey(enabled bool) ManagerOption {
+	return func(m *Manager) {
+		m.anyOfflineKey = enabled
+	}
+}
+
+// WithManagerClock sets the Clock used by the Manager's own time-based logic, such
+// as the license cache grace period (default: the system clock). The offline
+// validator has its own clock; see WithValidatorClock.
+func WithManagerClock(c Clock) ManagerOption {
+	return func(m *Manager) {
+		m.clock = c
+	}
+}
+
 // NewManager creates a new license Manager.
 func NewManager(opts ...ManagerOption) *Manager {
-	m := &Manager{}
+	m := &Manager{
+		clock:             SystemClock{},
+		state:             StatusUnknown,
+		heartbeatInterval: defaultHeartbeatInterval,
+		heartbeatJitter:   defaultHeartbeatJitter,
+	}
 	for _, opt := range opts {
 		opt(m)
 	}
@@ -51,12 +125,29 @@ func (m *Manager) resolveFingerprint() (string, error) {
 
 // ValidateAndEnforce performs full license validation with hardware enforcement:
 //  1. Resolves a machine fingerprint (client-level or auto-generated)
-//  2. Validates the license via the online client
+//  2. Validates the license via the online client. If the server is unreachable,
+//     falls back to the last-known-good cache (see WithLicenseCache), then to the
+//     offline license file (see WithOfflineFallback), if configured
 //  3. Extracts hardware limits from features
 //  4. Checks CPU limits on this machine
+//
+// If the Manager has no online client but has an offline validator, it behaves
+// like ValidateOffline. The returned LicenseInfo's Source records which path
+// produced the answer. The outcome updates the Manager's license state (see Status
+// and Subscribe).
 func (m *Manager) ValidateAndEnforce(ctx context.Context, licenseKey string) (*LicenseInfo, error) {
+	info, err := m.validateAndEnforce(ctx, licenseKey)
+	m.observe(licenseKey, info, err)
+	return info, err
+}
+
+// validateAndEnforce implements ValidateAndEnforce.
+func (m *Manager) validateAndEnforce(ctx context.Context, licenseKey string) (*LicenseInfo, error) {
 	if m.client == nil {
-		return nil, fmt.Errorf("online client is required for ValidateAndEnforce")
+		if m.offline != nil {
+			return m.validateOffline(licenseKey)
+		}
+		return nil, fmt.Errorf("online client or offline validator is required for ValidateAndEnforce")
 	}
 
 	// 1. Resolve fingerprint
@@ -71,32 +162,145 @@ func (m *Manager) ValidateAndEnforce(ctx context.Context, licenseKey string) (*L
 		Fingerprint: fingerprint,
 	})
 	if err != nil {
+		if isUnavailable(err) {
+			return m.validateUnreachable(licenseKey, fingerprint, err)
+		}
+		if errors.Is(err, ErrLicenseExpired) {
+			if info := m.expiredGrace(licenseKey, fingerprint); info != nil {
+				if err := CheckCPU(ExtractHardwareLimits(info.Features)); err != nil {
+					return nil, err
+				}
+				return info, nil
+			}
+		}
+		if isRejection(err) {
+			m.dropCache(licenseKey)
+		}
 		return nil, fmt.Errorf("validate license: %w", err)
 	}
-	if !resp.Valid {
+	info := m.responseInfo(licenseKey, fingerprint, resp, SourceOnline)
+	if info == nil {
+		m.dropCache(licenseKey)
 		return &LicenseInfo{
 			Valid:       false,
 			LicenseKey:  licenseKey,
 			Fingerprint: fingerprint,
+			Source:      SourceOnline,
+			Status:      m.rejectedStatus(resp),
 		}, nil
 	}
 
+	if info.Status == StatusValid {
+		m.saveCache(licenseKey, fingerprint, resp)
+	}
+
 	// 3. Extract hardware limits
-	limits := ExtractHardwareLimits(resp.Features)
+	limits := ExtractHardwareLimits(info.Features)
 
 	// 4. Check CPU
 	if err := CheckCPU(limits); err != nil {
 		return nil, err
 	}
 
-	return &LicenseInfo{
-		Valid:       true,
-		LicenseKey:  licenseKey,
-		Plan:        resp.Plan,
-		Features:    resp.Features,
-		ExpiresAt:   resp.ExpiresAt,
-		Fingerprint: fingerprint,
-	}, nil
+	return info, nil
+}
+
+// ValidateOffline performs license validation with hardware enforcement using only
+// the offline validator and the license file set via WithOfflineLicenseFile:
+//  1. Resolves a machine fingerprint (client-level or auto-generated)
+//  2. Verifies the license file's signature and expiry
+//  3. Checks that the file's license_key matches licenseKey. An empty licenseKey is
+//     rejected with ErrLicenseKeyMismatch unless WithAnyOfflineLicenseKey is set
+//  4. Extracts hardware limits from features and checks CPU limits on this machine
+//
+// The outcome updates the Manager's license state (see Status and Subscribe).
+func (m *Manager) ValidateOffline(licenseKey string) (*LicenseInfo, error) {
+	info, err := m.validateOffline(licenseKey)
+	m.observe(licenseKey, info, err)
+	return info, err
+}
+
+// validateOffline implements ValidateOffline.
+func (m *Manager) validateOffline(licenseKey string) (*LicenseInfo, error) {
+	if m.offline == nil || m.offlineFile == "" {
+		return nil, fmt.Errorf("offline validator and license file are required for ValidateOffline")
+	}
+
+	fingerprint, err := m.resolveFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("resolve fingerprint: %w", err)
+	}
+
+	return m.validateOfflineFile(licenseKey, fingerprint)
+}
+
+// validateUnreachable serves a result while the license server cannot be reached:
+// first from the last-known-good cache, then from the offline license file.
+// If neither is configured or both fail, the returned error wraps all causes.
+func (m *Manager) validateUnreachable(licenseKey, fingerprint string, onlineErr error) (*LicenseInfo, error) {
+	err := fmt.Errorf("validate license: %w", onlineErr)
+	if m.cache != nil {
+		info, cacheErr := m.validateCached(licenseKey, fingerprint)
+		if cacheErr == nil {
+			return info, nil
+		}
+		err = fmt.Errorf("%w; license cache: %w", err, cacheErr)
+	}
+	if m.canFallback() {
+		info, offErr := m.validateOfflineFile(licenseKey, fingerprint)
+		if offErr == nil {
+			return info, nil
+		}
+		err = fmt.Errorf("%w; offline fallback: %w", err, offErr)
+	}
+	return nil, err
+}
+
+// canFallback reports whether the offline fallback is enabled and configured.
+func (m *Manager) canFallback() bool {
+	return m.offlineFallback && m.offline != nil && m.offlineFile != ""
+}
+
+// validateOfflineFile verifies the configured offline license file, checks that it
+// belongs to licenseKey and enforces its hardware limits on this machine.
+func (m *Manager) validateOfflineFile(licenseKey, fingerprint string) (*LicenseInfo, error) {
+	data, err := m.offline.verifyFile(m.offlineFile, fingerprint)
+	if errors.Is(err, ErrLicenseExpired) {
+		if remaining, ok := graceRemaining(data.ExpiresAt, m.clock.Now(), m.expiryGrace); ok {
+			data.Status, data.GraceRemaining, err = StatusGrace, remaining, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("verify offline license: %w", err)
+	}
+	switch {
+	case licenseKey == "" && !m.anyOfflineKey:
+		return nil, fmt.Errorf("%w: file is for %q, no license key requested", ErrLicenseKeyMismatch, data.LicenseKey)
+	case licenseKey != "" && data.LicenseKey != licenseKey:
+		return nil, fmt.Errorf("%w: file is for %q, expected %q", ErrLicenseKeyMismatch, data.LicenseKey, licenseKey)
+	}
+
+	features := data.EffectiveFeatures()
+	limits := ExtractHardwareLimits(features)
+	if err := CheckCPU(limits); err != nil {
+		return nil, err
+	}
+
+	info := &LicenseInfo{
+		Valid:          true,
+		LicenseKey:     data.LicenseKey,
+		Plan:           data.Plan,
+		Features:       features,
+		Fingerprint:    fingerprint,
+		Source:         SourceOffline,
+		Status:         data.Status,
+		GraceRemaining: data.GraceRemaining,
+	}
+	if !data.ExpiresAt.IsZero() {
+		expiresAt := data.ExpiresAt
+		info.ExpiresAt = &expiresAt
+	}
+	return info, nil
 }
 
 // ActivateNode activates this machine with the license server.
@@ -115,3 +319,58 @@ func (m *Manager) ActivateNode(ctx context.Context, licenseKey string) (*Activat
 		Fingerprint: fingerprint,
 	})
 }
+
+// DeactivateNode releases this machine's activation with the license server,
+// freeing the slot for another node. The fingerprint is resolved the same way as
+// in ActivateNode. Intended to be called on graceful shutdown (e.g. SIGTERM).
+func (m *Manager) DeactivateNode(ctx context.Context, licenseKey string) (*DeactivateResponse, error) {
+	if m.client == nil {
+		return nil, fmt.Errorf("online client is required for DeactivateNode")
+	}
+
+	fingerprint, err := m.resolveFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("resolve fingerprint: %w", err)
+	}
+
+	return m.client.Deactivate(ctx, DeactivateRequest{
+		LicenseKey:  licenseKey,
+		Fingerprint: fingerprint,
+	})
+}
+
+// NewOfflineActivationRequest creates an offline activation request file for this
+// machine, resolving the fingerprint the same way as ActivateNode. The customer
+// carries the file to a connected machine to obtain a signed response.
+func (m *Manager) NewOfflineActivationRequest(licenseKey string) ([]byte, error) {
+	fingerprint, err := m.resolveFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("resolve fingerprint: %w", err)
+	}
+	req, err := NewOfflineActivationRequest(licenseKey, fingerprint, m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return req.Marshal()
+}
+
+// AcceptOfflineActivation verifies the signed response to an offline activation
+// request and stores it as the Manager's offline license file (see
+// WithOfflineLicenseFile), after which ValidateOffline uses it.
+func (m *Manager) AcceptOfflineActivation(requestFile, responseFile []byte) (*OfflineLicenseData, error) {
+	if m.offline == nil || m.offlineFile == "" {
+		return nil, fmt.Errorf("offline validator and license file are required for AcceptOfflineActivation")
+	}
+	req, err := ParseOfflineActivationRequest(requestFile)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := m.resolveFingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("resolve fingerprint: %w", err)
+	}
+	if req.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("%w: request was created on another machine", ErrFingerprintMismatch)
+	}
+	return m.offline.AcceptActivationResponse(responseFile, req, m.offlineFile)
+}
@@ -0,0 +1,317 @@
+package cnwlicense_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// writeOfflineLicense signs data with a fresh key, writes the license file to a
+// temp dir and returns its path and the base64 public key.
+func writeOfflineLicense(t *testing.T, data cnwlicense.OfflineLicenseData) (string, string) {
+	t.Helper()
+	s := newSigner(t)
+	path := filepath.Join(t.TempDir(), "license.json")
+	if err := os.WriteFile(path, signLicense(t, s, data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, s.PublicKey()
+}
+
+// hybridManager builds a Manager with an online client pointed at serverURL and
+// an offline fallback to a freshly signed license file.
+func hybridManager(t *testing.T, serverURL string, fallback bool) *cnwlicense.Manager {
+	t.Helper()
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-TEST-1234",
+		Plan:       "offline-plan",
+		Features:   map[string]interface{}{"max_nodes": float64(3)},
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		IssuedAt:   time.Now(),
+	})
+	return cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(serverURL, "test-key", cnwlicense.WithFingerprint("node-fp"), cnwlicense.WithTimeout(time.Second))),
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+		cnwlicense.WithOfflineFallback(fallback),
+	)
+}
+
+func TestManager_DeactivateNode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/deactivate" {
+			t.Errorf("expected /v1/deactivate, got %s", r.URL.Path)
+		}
+		var req cnwlicense.DeactivateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.LicenseKey != "CNW-TEST-1234" {
+			t.Errorf("expected license key CNW-TEST-1234, got %s", req.LicenseKey)
+		}
+		if req.Fingerprint != "node-fp" {
+			t.Errorf("expected fingerprint node-fp, got %s", req.Fingerprint)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": cnwlicense.DeactivateResponse{ID: "act-001", Fingerprint: req.Fingerprint, ActivationRemaining: 2},
+		})
+	}))
+	defer server.Close()
+
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	resp, err := mgr.DeactivateNode(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ActivationRemaining != 2 {
+		t.Errorf("expected 2 remaining, got %d", resp.ActivationRemaining)
+	}
+}
+
+func TestManager_DeactivateNode_RequiresClient(t *testing.T) {
+	mgr := cnwlicense.NewManager()
+	if _, err := mgr.DeactivateNode(context.Background(), "CNW-TEST-1234"); err == nil {
+		t.Fatal("expected error without online client")
+	}
+}
+
+func TestManager_ValidateAndEnforce_OnlineSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cnwlicense.ValidateResponse{Valid: true, Plan: "online-plan"})
+	}))
+	defer server.Close()
+
+	info, err := hybridManager(t, server.URL, true).ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Source != cnwlicense.SourceOnline || info.Plan != "online-plan" {
+		t.Errorf("expected online result, got source=%s plan=%s", info.Source, info.Plan)
+	}
+}
+
+func TestManager_ValidateAndEnforce_FallbackOnNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	info, err := hybridManager(t, url, true).ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Valid {
+		t.Error("expected valid=true")
+	}
+	if info.Source != cnwlicense.SourceOffline {
+		t.Errorf("expected source offline, got %s", info.Source)
+	}
+	if info.Plan != "offline-plan" {
+		t.Errorf("expected plan offline-plan, got %s", info.Plan)
+	}
+	if info.ExpiresAt == nil {
+		t.Error("expected expires_at from offline license")
+	}
+}
+
+func TestManager_ValidateAndEnforce_FallbackOnUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	info, err := hybridManager(t, server.URL, true).ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Source != cnwlicense.SourceOffline {
+		t.Errorf("expected source offline, got %s", info.Source)
+	}
+}
+
+func TestManager_ValidateAndEnforce_NoFallbackOnRejection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{"code": "NOT_FOUND", "message": "license not found"},
+		})
+	}))
+	defer server.Close()
+
+	_, err := hybridManager(t, server.URL, true).ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if !errors.Is(err, cnwlicense.ErrLicenseNotFound) {
+		t.Errorf("expected ErrLicenseNotFound without fallback, got %v", err)
+	}
+
+	// An explicit valid=false answer is not a fallback trigger either.
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cnwlicense.ValidateResponse{Valid: false, Reason: "license is suspended"})
+	}))
+	defer invalid.Close()
+
+	info, err := hybridManager(t, invalid.URL, true).ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Valid || info.Source != cnwlicense.SourceOnline {
+		t.Errorf("expected invalid online result, got valid=%v source=%s", info.Valid, info.Source)
+	}
+}
+
+func TestManager_ValidateAndEnforce_FallbackDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	_, err := hybridManager(t, url, false).ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if err == nil {
+		t.Fatal("expected network error when fallback is disabled")
+	}
+}
+
+func TestManager_ValidateAndEnforce_FallbackExpired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-TEST-1234",
+		ExpiresAt:  time.Now().Add(-time.Hour),
+		IssuedAt:   time.Now().Add(-48 * time.Hour),
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(url, "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+		cnwlicense.WithOfflineFallback(true),
+	)
+	_, err := mgr.ValidateAndEnforce(context.Background(), "CNW-TEST-1234")
+	if !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired from offline fallback, got %v", err)
+	}
+}
+
+func TestManager_ValidateAndEnforce_OfflineOnly(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-AIRGAP",
+		Plan:       "enterprise",
+		Features:   map[string]interface{}{"max_nodes": float64(3)},
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		IssuedAt:   time.Now(),
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+	)
+
+	info, err := mgr.ValidateAndEnforce(context.Background(), "CNW-AIRGAP")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Valid || info.Source != cnwlicense.SourceOffline {
+		t.Errorf("expected valid offline result, got valid=%v source=%s", info.Valid, info.Source)
+	}
+	if info.Plan != "enterprise" {
+		t.Errorf("expected plan enterprise, got %s", info.Plan)
+	}
+	if cnwlicense.ExtractHardwareLimits(info.Features).MaxNodes != 3 {
+		t.Errorf("expected features from license file, got %v", info.Features)
+	}
+	if info.Fingerprint == "" {
+		t.Error("expected resolved fingerprint")
+	}
+
+	// An empty license key is rejected unless explicitly allowed.
+	if _, err := mgr.ValidateOffline(""); !errors.Is(err, cnwlicense.ErrLicenseKeyMismatch) {
+		t.Errorf("expected ErrLicenseKeyMismatch for empty license key, got %v", err)
+	}
+	cnwlicense.WithAnyOfflineLicenseKey(true)(mgr)
+	info, err = mgr.ValidateOffline("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.LicenseKey != "CNW-AIRGAP" {
+		t.Errorf("expected license key from file, got %s", info.LicenseKey)
+	}
+}
+
+func TestManager_ValidateOffline_KeyMismatch(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-OTHER",
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		IssuedAt:   time.Now(),
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+	)
+
+	_, err := mgr.ValidateOffline("CNW-AIRGAP")
+	if !errors.Is(err, cnwlicense.ErrLicenseKeyMismatch) {
+		t.Errorf("expected ErrLicenseKeyMismatch, got %v", err)
+	}
+}
+
+func TestManager_ValidateOffline_CPULimit(t *testing.T) {
+	if runtime.NumCPU() < 2 {
+		t.Skip("needs at least 2 CPUs")
+	}
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-AIRGAP",
+		Features:   map[string]interface{}{"max_cpu_per_node": float64(1)},
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		IssuedAt:   time.Now(),
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+	)
+
+	_, err := mgr.ValidateAndEnforce(context.Background(), "CNW-AIRGAP")
+	if !errors.Is(err, cnwlicense.ErrCPULimitExceeded) {
+		t.Errorf("expected ErrCPULimitExceeded, got %v", err)
+	}
+}
+
+func TestManager_ValidateOffline_RequiresFile(t *testing.T) {
+	mgr := cnwlicense.NewManager(cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator()))
+	if _, err := mgr.ValidateOffline("CNW-AIRGAP"); err == nil {
+		t.Fatal("expected error without license file")
+	}
+	if _, err := cnwlicense.NewManager().ValidateAndEnforce(context.Background(), "CNW-AIRGAP"); err == nil {
+		t.Fatal("expected error without online client or offline validator")
+	}
+}
+
+func TestManager_ValidateOffline_NodeLockedUsesClientFingerprint(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey:   "CNW-NODE",
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+		IssuedAt:     time.Now(),
+		Fingerprints: []string{"node-fp"},
+	})
+	newManager := func(fp string) *cnwlicense.Manager {
+		return cnwlicense.NewManager(
+			cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient("http://127.0.0.1:0", "test-key", cnwlicense.WithFingerprint(fp))),
+			cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+			cnwlicense.WithOfflineLicenseFile(path),
+		)
+	}
+
+	if _, err := newManager("node-fp").ValidateOffline("CNW-NODE"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := newManager("other-fp").ValidateOffline("CNW-NODE"); !errors.Is(err, cnwlicense.ErrFingerprintMismatch) {
+		t.Errorf("expected ErrFingerprintMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package cnwlicense
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Authorize checks the Manager's most recent validation result (see Current)
+// without contacting the license server, and returns it if the license is usable
+// and every listed feature is enabled. Its Valid and Status fields decide,
+// including a grace period granted by either the Manager or the
+// OfflineValidator, but expiry is re-checked against the Manager's clock: a
+// result kept current while the server is unreachable stops being honored once
+// its ExpiresAt plus that grace period has passed. It returns:
+//   - ErrNoLicense if no usable result is available (no validation yet, license
+//     rejected, server unreachable with no earlier valid result)
+//   - ErrLicenseExpired if the license has expired, including when the result
+//     was valid but its expiry (plus any grace period) has since passed
+//   - ErrLicenseInactive if the license was revoked or suspended
+//   - ErrFeatureNotLicensed if a feature is missing, disabled or not a boolean
+//
+// A feature is enabled if it parses as true (true, "true", 1, ...); any other
+// value, including ones that cannot be parsed, denies access. While the license
+// is in its grace period, the degraded feature set is checked.
+func (m *Manager) Authorize(features ...string) (*LicenseInfo, error) {
+	info := m.Current()
+	if info == nil {
+		return nil, fmt.Errorf("%w: license has not been validated", ErrNoLicense)
+	}
+	switch info.Status {
+	case StatusExpired:
+		return info, ErrLicenseExpired
+	case StatusRevoked:
+		return info, ErrLicenseInactive
+	}
+	if !info.Valid {
+		return info, fmt.Errorf("%w: license status is %s", ErrNoLicense, info.Status)
+	}
+	if info.ExpiresAt != nil && m.clock.Now().After(info.ExpiresAt.Add(m.graceFor(info))) {
+		return info, ErrLicenseExpired
+	}
+	for _, key := range features {
+		if !featureEnabled(info.Features, key) {
+			return info, fmt.Errorf("%w: %q", ErrFeatureNotLicensed, key)
+		}
+	}
+	return info, nil
+}
+
+// graceFor returns the post-expiry grace period that applies to info: the
+// Manager's, or the OfflineValidator's if longer for an offline result.
+func (m *Manager) graceFor(info *LicenseInfo) time.Duration {
+	grace := m.expiryGrace
+	if info.Source == SourceOffline && m.offline != nil {
+		grace = max(grace, m.offline.expiryGrace)
+	}
+	return grace
+}
+
+// featureEnabled reports whether key is present and parses as true. Values that
+// are not booleans fail closed.
+func featureEnabled(f Features, key string) bool {
+	enabled, err := f.BoolE(key)
+	return err == nil && enabled
+}
+
+// MiddlewareOption configures the middleware returned by Manager.Middleware.
+type MiddlewareOption func(*middleware)
+
+type middleware struct {
+	features      []string
+	licenseStatus int // response status when the license is not usable
+	featureStatus int // response status when a feature is not licensed
+	onDenied      func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// WithRequiredFeatures makes the middleware require every listed feature.
+func WithRequiredFeatures(features ...string) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.features = append(mw.features, features...)
+	}
+}
+
+// WithDeniedStatus sets the HTTP status codes returned when the license is not
+// usable (default: 402 Payment Required) and when a required feature is not
+// licensed (default: 403 Forbidden).
+func WithDeniedStatus(license, feature int) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.licenseStatus = license
+		mw.featureStatus = feature
+	}
+}
+
+// WithDeniedHandler replaces the default JSON error response for denied requests.
+// err is the error returned by Authorize.
+func WithDeniedHandler(fn func(w http.ResponseWriter, r *http.Request, err error)) MiddlewareOption {
+	return func(mw *middleware) {
+		mw.onDenied = fn
+	}
+}
+
+// Middleware returns HTTP middleware that admits a request only if Authorize
+// succeeds for the configured features (see WithRequiredFeatures). It reads the
+// Manager's latest validation result and never calls the license server, so keep
+// that result fresh with periodic ValidateAndEnforce calls. Admitted requests carry
+// the LicenseInfo in their context (see LicenseFromContext).
+//
+// Denied requests get a JSON body in the license server's error format:
+//
+//	{"error": {"code": "FEATURE_NOT_LICENSED", "message": "..."}}
+func (m *Manager) Middleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	mw := &middleware{
+		licenseStatus: http.StatusPaymentRequired,
+		featureStatus: http.StatusForbidden,
+	}
+	for _, opt := range opts {
+		opt(mw)
+	}
+	if mw.onDenied == nil {
+		mw.onDenied = mw.writeDenied
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info, err := m.Authorize(mw.features...)
+			if err != nil {
+				mw.onDenied(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ContextWithLicense(r.Context(), info)))
+		})
+	}
+}
+
+// writeDenied writes the default JSON error response.
+func (mw *middleware) writeDenied(w http.ResponseWriter, r *http.Request, err error) {
+	status := mw.licenseStatus
+	if errors.Is(err, ErrFeatureNotLicensed) {
+		status = mw.featureStatus
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{
+			"code":    DenialCode(err),
+			"message": err.Error(),
+		},
+	})
+}
+
+// DenialCode returns the machine-readable code for an Authorize error, as used in
+// denied HTTP responses and gRPC error details: FEATURE_NOT_LICENSED,
+// LICENSE_EXPIRED, LICENSE_INACTIVE or LICENSE_REQUIRED.
+func DenialCode(err error) string {
+	switch {
+	case errors.Is(err, ErrFeatureNotLicensed):
+		return "FEATURE_NOT_LICENSED"
+	case errors.Is(err, ErrLicenseExpired):
+		return "LICENSE_EXPIRED"
+	case errors.Is(err, ErrLicenseInactive):
+		return "LICENSE_INACTIVE"
+	}
+	return "LICENSE_REQUIRED"
+}
+
+type licenseInfoKey struct{}
+
+// ContextWithLicense returns a copy of ctx carrying info, for retrieval with
+// LicenseFromContext. Used by Manager.Middleware and the cnwgrpc interceptors.
+func ContextWithLicense(ctx context.Context, info *LicenseInfo) context.Context {
+	return context.WithValue(ctx, licenseInfoKey{}, info)
+}
+
+// LicenseFromContext returns the LicenseInfo stored by Manager.Middleware (or
+// ContextWithLicense).
+func LicenseFromContext(ctx context.Context) (*LicenseInfo, bool) {
+	info, ok := ctx.Value(licenseInfoKey{}).(*LicenseInfo)
+	return info, ok
+}
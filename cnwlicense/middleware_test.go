@@ -0,0 +1,218 @@
+package cnwlicense_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/cnwlicensetest"
+)
+
+// serveThrough runs a request through mw and returns the recorded response.
+func serveThrough(mw func(http.Handler) http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, ok := cnwlicense.LicenseFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no license in context", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(info.Plan))
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	return rec
+}
+
+func decodeDenial(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode denial body: %v", err)
+	}
+	if body.Error.Message == "" {
+		t.Error("expected error message")
+	}
+	return body.Error.Code
+}
+
+func TestManager_Middleware(t *testing.T) {
+	var calls atomic.Int32
+	var resp atomic.Pointer[cnwlicense.ValidateResponse]
+	resp.Store(&cnwlicense.ValidateResponse{
+		Valid:    true,
+		Plan:     "enterprise",
+		Features: cnwlicense.Features{"reports": true, "sso": "false", "max_users": float64(10)},
+	})
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		calls.Add(1)
+		return resp.Load()
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+
+	// Before any validation, requests are denied.
+	rec := serveThrough(mgr.Middleware())
+	if rec.Code != http.StatusPaymentRequired || decodeDenial(t, rec) != "LICENSE_REQUIRED" {
+		t.Errorf("expected 402 LICENSE_REQUIRED before validation, got %d", rec.Code)
+	}
+
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+	before := calls.Load()
+
+	rec = serveThrough(mgr.Middleware(cnwlicense.WithRequiredFeatures("reports", "max_users")))
+	if rec.Code != http.StatusOK || rec.Body.String() != "enterprise" {
+		t.Errorf("expected 200 with license in context, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	for _, feature := range []string{"sso", "missing"} {
+		rec = serveThrough(mgr.Middleware(cnwlicense.WithRequiredFeatures("reports", feature)))
+		if rec.Code != http.StatusForbidden || decodeDenial(t, rec) != "FEATURE_NOT_LICENSED" {
+			t.Errorf("expected 403 FEATURE_NOT_LICENSED for %s, got %d", feature, rec.Code)
+		}
+	}
+	if calls.Load() != before {
+		t.Error("middleware must not call the license server")
+	}
+
+	// A suspended license is denied once the Manager has seen it.
+	resp.Store(&cnwlicense.ValidateResponse{Valid: false, Reason: "license is suspended"})
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+	rec = serveThrough(mgr.Middleware())
+	if rec.Code != http.StatusPaymentRequired || decodeDenial(t, rec) != "LICENSE_INACTIVE" {
+		t.Errorf("expected 402 LICENSE_INACTIVE, got %d", rec.Code)
+	}
+}
+
+func TestManager_Authorize_OfflineGrace(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey:       "CNW-MW",
+		ExpiresAt:        time.Now().Add(-time.Hour),
+		IssuedAt:         time.Now().Add(-24 * time.Hour),
+		Features:         cnwlicense.Features{"reports": true, "sso": true},
+		DegradedFeatures: cnwlicense.Features{"reports": true},
+	})
+	// The grace period comes from the validator; the Manager has none of its own.
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub), cnwlicense.WithExpiryGrace(24*time.Hour))),
+		cnwlicense.WithOfflineLicenseFile(path),
+	)
+	if _, err := mgr.ValidateOffline("CNW-MW"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := mgr.Authorize("reports")
+	if err != nil {
+		t.Fatalf("expected access during grace, got %v", err)
+	}
+	if info.Status != cnwlicense.StatusGrace {
+		t.Errorf("expected status grace, got %s", info.Status)
+	}
+	if _, err := mgr.Authorize("sso"); !errors.Is(err, cnwlicense.ErrFeatureNotLicensed) {
+		t.Errorf("expected degraded feature set during grace, got %v", err)
+	}
+}
+
+func TestManager_Authorize_Expired(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC()
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: false, Reason: "license expired", ExpiresAt: &past}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+
+	if _, err := mgr.Authorize(); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired, got %v", err)
+	}
+	rec := serveThrough(mgr.Middleware())
+	if rec.Code != http.StatusPaymentRequired || decodeDenial(t, rec) != "LICENSE_EXPIRED" {
+		t.Errorf("expected 402 LICENSE_EXPIRED, got %d", rec.Code)
+	}
+}
+
+func TestManager_Authorize_ExpiredWhileUnreachable(t *testing.T) {
+	clock := cnwlicensetest.NewFakeClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	expiresAt := clock.Now().Add(time.Hour)
+	var up atomic.Bool
+	up.Store(true)
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		if !up.Load() {
+			return nil
+		}
+		return &cnwlicense.ValidateResponse{Valid: true, ExpiresAt: &expiresAt, Features: cnwlicense.Features{"sso": true}}
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithManagerClock(clock),
+	)
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+
+	// The last valid result stays current while the server is down, but only
+	// until it expires.
+	up.Store(false)
+	clock.Advance(30 * 24 * time.Hour)
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+	if _, err := mgr.Authorize("sso"); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected ErrLicenseExpired for a stale result past its expiry, got %v", err)
+	}
+}
+
+func TestManager_Authorize_FeatureValues(t *testing.T) {
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true, Features: cnwlicense.Features{
+			"bool": true, "string": "true", "number": float64(1),
+			"off": false, "off_string": "false", "zero": float64(0),
+			"word": "no", "fraction": 0.0, "object": map[string]interface{}{"enabled": true},
+		}}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+
+	// Only values that parse as true enable a feature; anything else fails closed.
+	for key, want := range map[string]bool{
+		"bool": true, "string": true, "number": true,
+		"off": false, "off_string": false, "zero": false,
+		"word": false, "fraction": false, "object": false, "missing": false,
+	} {
+		_, err := mgr.Authorize(key)
+		if got := err == nil; got != want || (err != nil && !errors.Is(err, cnwlicense.ErrFeatureNotLicensed)) {
+			t.Errorf("Authorize(%q) = %v, want enabled=%v", key, err, want)
+		}
+	}
+}
+
+func TestManager_Middleware_Options(t *testing.T) {
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true, Features: cnwlicense.Features{}}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	mgr.ValidateAndEnforce(context.Background(), "CNW-MW")
+
+	rec := serveThrough(mgr.Middleware(
+		cnwlicense.WithRequiredFeatures("reports"),
+		cnwlicense.WithDeniedStatus(http.StatusUnauthorized, http.StatusNotFound),
+	))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected custom feature status 404, got %d", rec.Code)
+	}
+
+	var denied error
+	rec = serveThrough(mgr.Middleware(
+		cnwlicense.WithRequiredFeatures("reports"),
+		cnwlicense.WithDeniedHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			denied = err
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	))
+	if rec.Code != http.StatusTeapot || !errors.Is(denied, cnwlicense.ErrFeatureNotLicensed) {
+		t.Errorf("expected custom handler with ErrFeatureNotLicensed, got %d %v", rec.Code, denied)
+	}
+}
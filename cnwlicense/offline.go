@@ -6,41 +6,114 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"sync"
 	"time"
 )
 
+// defaultMaxClockSkew is how far in the future a license's issued_at may be
+// before it is rejected, to tolerate clock drift between issuer and machine.
+const defaultMaxClockSkew = 5 * time.Minute
+
 // OfflineValidator verifies Ed25519-signed offline license files.
 // It is compatible with the server's crypto.SignJSON signing format.
 type OfflineValidator struct {
-	trustedPublicKey string // base64-encoded Ed25519 public key
+	trustedPublicKey string        // base64-encoded Ed25519 public key
+	trustedKeys      []TrustedKey  // keyring for key rotation
+	fingerprint      string        // machine fingerprint for node-locked licenses
+	maxClockSkew     time.Duration // tolerated issued_at drift; negative disables the check
+	clock            Clock
+	expiryGrace      time.Duration // post-expiry grace period
+
+	hwmStore     HighWaterMarkStore // latest observed time, for clock rollback detection
+	hwmTolerance time.Duration
+	hwmMu        sync.Mutex // serializes load/save of the high-water mark
+
+	activationMaxAge time.Duration // oldest offline activation request accepted; negative disables the check
+
+	pendingRevocations []func() error // revocation lists to load after options are applied
+
+	mu                  sync.RWMutex
+	revocations         map[string]RevokedLicense // by license key
+	revocationsIssuedAt time.Time
+	revocationErr       error // set if a revocation list option failed to load
+}
+
+// TrustedKey is a trusted Ed25519 signing key in a keyring (see WithTrustedKeys).
+type TrustedKey struct {
+	// ID identifies the key; license files name it in their key_id field.
+	ID string
+	// PublicKey is the base64-encoded Ed25519 public key.
+	PublicKey string
+	// NotBefore and NotAfter bound when the key is accepted. Zero means unbounded.
+	// Set NotAfter when retiring a key so it stops being accepted after that date.
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// activeAt reports whether the key is within its validity window at t.
+func (k TrustedKey) activeAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+	if !k.NotAfter.IsZero() && t.After(k.NotAfter) {
+		return false
+	}
+	return true
 }
 
 // NewOfflineValidator creates a new offline license validator.
 func NewOfflineValidator(opts ...OfflineOption) *OfflineValidator {
-	v := &OfflineValidator{}
+	v := &OfflineValidator{
+		maxClockSkew:     defaultMaxClockSkew,
+		activationMaxAge: defaultActivationRequestMaxAge,
+		clock:            SystemClock{},
+	}
 	for _, opt := range opts {
 		opt(v)
 	}
+	// Revocation lists are verified with the trusted keys, so they are
+	// loaded only once all options have been applied.
+	for _, load := range v.pendingRevocations {
+		if err := load(); err != nil {
+			v.revocationErr = err
+			break
+		}
+	}
+	v.pendingRevocations = nil
 	return v
 }
 
 // VerifyFile reads a license file from disk and verifies its signature.
 func (v *OfflineValidator) VerifyFile(filePath string) (*OfflineLicenseData, error) {
+	return v.verifyFile(filePath, "")
+}
+
+// verifyFile is VerifyFile with an explicit machine fingerprint (see verify).
+func (v *OfflineValidator) verifyFile(filePath, fingerprint string) (*OfflineLicenseData, error) {
 	raw, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("read license file: %w", err)
 	}
-	return v.Verify(raw)
+	return v.verify(raw, fingerprint)
 }
 
 // Verify verifies a raw JSON license file and returns the license data.
 //
 // The verification process matches the server's crypto.SignJSON format:
-//  1. Parse the outer envelope (license as raw JSON, signature, public_key)
-//  2. Decode the public key and signature from base64
+//  1. Parse the outer envelope (license as raw JSON, signature, public_key, key_id)
+//  2. Select the public key(s): the trusted keyring, the trusted key, or the embedded key
 //  3. Verify ed25519.Verify(pubKey, rawLicenseBytes, signature)
-//  4. Parse and validate the license data (expiration check)
+//  4. Parse and validate the license data (clock rollback, revocation, machine binding, not-before,
+//     issued-at and expiration checks)
 func (v *OfflineValidator) Verify(raw []byte) (*OfflineLicenseData, error) {
+	return v.verify(raw, "")
+}
+
+// verify implements Verify. fingerprint is the machine fingerprint node-locked
+// licenses are checked against; if empty, the validator's configured
+// fingerprint or GenerateFingerprint() is used.
+func (v *OfflineValidator) verify(raw []byte, fingerprint string) (*OfflineLicenseData, error) {
 	var file OfflineLicenseFile
 	if err := json.Unmarshal(raw, &file); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrLicenseFileInvalid, err)
@@ -50,34 +123,12 @@ func (v *OfflineValidator) Verify(raw []byte) (*OfflineLicenseData, error) {
 		return nil, ErrLicenseFileInvalid
 	}
 
-	// Determine which public key to use
-	pubKeyBase64 := file.PublicKey
-	if v.trustedPublicKey != "" {
-		pubKeyBase64 = v.trustedPublicKey
-	}
-	if pubKeyBase64 == "" {
-		return nil, ErrPublicKeyInvalid
-	}
-
-	pubKeyBytes, err := base64.StdEncoding.DecodeString(pubKeyBase64)
-	if err != nil {
-		return nil, fmt.Errorf("%w: base64 decode: %v", ErrPublicKeyInvalid, err)
-	}
-	if len(pubKeyBytes) != ed25519.PublicKeySize {
-		return nil, fmt.Errorf("%w: key length %d, expected %d", ErrPublicKeyInvalid, len(pubKeyBytes), ed25519.PublicKeySize)
-	}
-
-	sigBytes, err := base64.StdEncoding.DecodeString(file.Signature)
-	if err != nil {
-		return nil, fmt.Errorf("%w: signature decode: %v", ErrSignatureInvalid, err)
-	}
-
 	// Verify the signature over the raw license JSON bytes.
 	// The server signs json.Marshal(OfflineLicenseData), so we verify
 	// against the raw JSON bytes of the "license" field.
-	pubKey := ed25519.PublicKey(pubKeyBytes)
-	if !ed25519.Verify(pubKey, file.License, sigBytes) {
-		return nil, ErrSignatureInvalid
+	keyID, err := v.verifySignature(file.License, file.Signature, file.PublicKey, file.KeyID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse the license data
@@ -85,12 +136,155 @@ func (v *OfflineValidator) Verify(raw []byte) (*OfflineLicenseData, error) {
 	if err := json.Unmarshal(file.License, &data); err != nil {
 		return nil, fmt.Errorf("%w: parse license data: %v", ErrLicenseFileInvalid, err)
 	}
+	data.VerifiedKeyID = keyID
+	now := v.clock.Now()
+
+	// Detect a clock set back behind the latest observed time. The signature
+	// has verified, so the mark is raised even if the checks below fail; expiry
+	// and revocation are checked against the later of the clock and the mark.
+	checkAt := now
+	if v.hwmStore != nil {
+		if checkAt, err = v.observeTime(now, data.IssuedAt); err != nil {
+			return &data, err
+		}
+	}
+
+	// Check revocation — like expiration, data is returned alongside the error.
+	if err := v.checkRevoked(data.LicenseKey, checkAt); err != nil {
+		return &data, err
+	}
+
+	// Check machine binding for node-locked licenses.
+	if err := v.checkFingerprint(&data, fingerprint); err != nil {
+		return &data, err
+	}
+
+	// Check the validity window start against the real clock, so that the
+	// high-water mark cannot mask an issued_at in the future.
+	if err := v.checkNotBefore(&data, now); err != nil {
+		return &data, err
+	}
 
 	// Check expiration — return data alongside the error so callers can
 	// still access plan, features, license_key etc. for expired licenses.
-	if !data.ExpiresAt.IsZero() && data.ExpiresAt.Before(time.Now()) {
-		return &data, ErrLicenseExpired
+	data.Status = StatusValid
+	if !data.ExpiresAt.IsZero() && data.ExpiresAt.Before(checkAt) {
+		remaining, ok := graceRemaining(data.ExpiresAt, checkAt, v.expiryGrace)
+		if !ok {
+			return &data, ErrLicenseExpired
+		}
+		data.Status = StatusGrace
+		data.GraceRemaining = remaining
 	}
 
 	return &data, nil
 }
+
+// checkNotBefore returns ErrLicenseNotYetValid if the license's not_before is
+// still ahead, and ErrLicenseIssuedInFuture if its issued_at is later than now
+// plus the tolerated clock skew (a wrong clock on either side, or a forged file).
+func (v *OfflineValidator) checkNotBefore(data *OfflineLicenseData, now time.Time) error {
+	if data.NotBefore != nil && now.Before(*data.NotBefore) {
+		return fmt.Errorf("%w: starts at %s", ErrLicenseNotYetValid, data.NotBefore.Format(time.RFC3339))
+	}
+	if v.maxClockSkew >= 0 && !data.IssuedAt.IsZero() && data.IssuedAt.After(now.Add(v.maxClockSkew)) {
+		return fmt.Errorf("%w: issued at %s", ErrLicenseIssuedInFuture, data.IssuedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkFingerprint returns ErrFingerprintMismatch if data is node-locked and the
+// machine fingerprint is not among its allowed fingerprints.
+func (v *OfflineValidator) checkFingerprint(data *OfflineLicenseData, fingerprint string) error {
+	if len(data.Fingerprints) == 0 {
+		return nil
+	}
+	if fingerprint == "" {
+		fingerprint = v.fingerprint
+	}
+	if fingerprint == "" {
+		fp, err := GenerateFingerprint()
+		if err != nil {
+			return fmt.Errorf("resolve fingerprint: %w", err)
+		}
+		fingerprint = fp
+	}
+	if !slices.Contains(data.Fingerprints, fingerprint) {
+		return ErrFingerprintMismatch
+	}
+	return nil
+}
+
+// verifySignature checks an Ed25519 signature over payload and returns the ID of
+// the key that verified it.
+//
+// If the validator has trusted keys, only those are used: the key named by keyID
+// (plus keys without an ID), or all of them when keyID is empty. Otherwise the
+// public key embedded in the envelope is used.
+func (v *OfflineValidator) verifySignature(payload []byte, signature, embeddedKey, keyID string) (string, error) {
+	keys := v.keyring()
+	if len(keys) == 0 {
+		if embeddedKey == "" {
+			return "", ErrPublicKeyInvalid
+		}
+		keys = []TrustedKey{{PublicKey: embeddedKey}}
+	} else if keyID != "" {
+		var matched []TrustedKey
+		for _, k := range keys {
+			if k.ID == keyID || k.ID == "" {
+				matched = append(matched, k)
+			}
+		}
+		if len(matched) == 0 {
+			return "", fmt.Errorf("%w: unknown key id %q", ErrPublicKeyInvalid, keyID)
+		}
+		keys = matched
+	}
+
+	pubKeys := make([]ed25519.PublicKey, len(keys))
+	for i, k := range keys {
+		pub, err := decodePublicKey(k.PublicKey)
+		if err != nil {
+			return "", err
+		}
+		pubKeys[i] = pub
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", fmt.Errorf("%w: signature decode: %v", ErrSignatureInvalid, err)
+	}
+
+	now := v.clock.Now()
+	for i, k := range keys {
+		if !ed25519.Verify(pubKeys[i], payload, sigBytes) {
+			continue
+		}
+		if !k.activeAt(now) {
+			return "", fmt.Errorf("%w: key %q", ErrKeyRetired, k.ID)
+		}
+		return k.ID, nil
+	}
+	return "", ErrSignatureInvalid
+}
+
+// keyring returns all configured trusted keys.
+func (v *OfflineValidator) keyring() []TrustedKey {
+	keys := v.trustedKeys
+	if v.trustedPublicKey != "" {
+		keys = append(keys[:len(keys):len(keys)], TrustedKey{PublicKey: v.trustedPublicKey})
+	}
+	return keys
+}
+
+// decodePublicKey decodes a base64-encoded Ed25519 public key.
+func decodePublicKey(pubKeyBase64 string) (ed25519.PublicKey, error) {
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(pubKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: base64 decode: %v", ErrPublicKeyInvalid, err)
+	}
+	if len(pubKeyBytes) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: key length %d, expected %d", ErrPublicKeyInvalid, len(pubKeyBytes), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(pubKeyBytes), nil
+}
@@ -0,0 +1,164 @@
+package cnwlicense
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// OfflineActivationRequest is the payload of an offline activation request file.
+// It carries the same machine details as ActivateRequest so that a connected
+// machine can register the activation on the customer's behalf.
+type OfflineActivationRequest struct {
+	LicenseKey  string    `json:"license_key"`
+	Fingerprint string    `json:"fingerprint"`
+	Hostname    string    `json:"hostname"`
+	OS          string    `json:"os,omitempty"`
+	Nonce       string    `json:"nonce"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// defaultActivationRequestMaxAge is how long an offline activation request stays
+// acceptable, covering the round trip to a connected machine and back.
+const defaultActivationRequestMaxAge = 7 * 24 * time.Hour
+
+// OfflineActivationRequestFile is the envelope of an offline activation request.
+// The request is kept as raw JSON and Checksum is the hex SHA-256 of its exact
+// bytes, which detects corruption or editing in transit.
+type OfflineActivationRequestFile struct {
+	Request  json.RawMessage `json:"request"`
+	Checksum string          `json:"checksum"`
+}
+
+// NewOfflineActivationRequest creates an activation request for this machine,
+// created at now. If fingerprint is empty, GenerateFingerprint() is used.
+func NewOfflineActivationRequest(licenseKey, fingerprint string, now time.Time) (*OfflineActivationRequest, error) {
+	if licenseKey == "" {
+		return nil, fmt.Errorf("%w: license key is required", ErrActivationRequestInvalid)
+	}
+	if fingerprint == "" {
+		fp, err := GenerateFingerprint()
+		if err != nil {
+			return nil, fmt.Errorf("resolve fingerprint: %w", err)
+		}
+		fingerprint = fp
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("get hostname: %w", err)
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return &OfflineActivationRequest{
+		LicenseKey:  licenseKey,
+		Fingerprint: fingerprint,
+		Hostname:    hostname,
+		OS:          runtime.GOOS,
+		Nonce:       hex.EncodeToString(nonce),
+		CreatedAt:   now.UTC(),
+	}, nil
+}
+
+// Marshal encodes the request as a checksummed request file.
+func (r *OfflineActivationRequest) Marshal() ([]byte, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("marshal activation request: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return json.MarshalIndent(OfflineActivationRequestFile{
+		Request:  raw,
+		Checksum: hex.EncodeToString(sum[:]),
+	}, "", "  ")
+}
+
+// ParseOfflineActivationRequest parses a request file produced by Marshal,
+// verifying its checksum and required fields.
+func ParseOfflineActivationRequest(raw []byte) (*OfflineActivationRequest, error) {
+	var file OfflineActivationRequestFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrActivationRequestInvalid, err)
+	}
+	if len(file.Request) == 0 || file.Checksum == "" {
+		return nil, ErrActivationRequestInvalid
+	}
+
+	// MarshalIndent re-indents the embedded request; compact it back to the
+	// bytes the checksum was computed over.
+	req, err := compactJSON(file.Request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrActivationRequestInvalid, err)
+	}
+	sum := sha256.Sum256(req)
+	if hex.EncodeToString(sum[:]) != file.Checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrActivationRequestInvalid)
+	}
+
+	var r OfflineActivationRequest
+	if err := json.Unmarshal(req, &r); err != nil {
+		return nil, fmt.Errorf("%w: parse request: %v", ErrActivationRequestInvalid, err)
+	}
+	if r.LicenseKey == "" || r.Fingerprint == "" || r.Hostname == "" {
+		return nil, fmt.Errorf("%w: license_key, fingerprint and hostname are required", ErrActivationRequestInvalid)
+	}
+	return &r, nil
+}
+
+// VerifyActivationResponse verifies a server-signed activation response for req.
+// The response is a regular offline license file that must be signed by a trusted
+// key, belong to req.LicenseKey, be node-locked to req.Fingerprint and carry
+// req.Nonce as its activation_nonce, so that only a response issued for this very
+// request is accepted. Requests older than the validator's maximum request age
+// (see WithActivationRequestMaxAge) are rejected with ErrActivationRequestExpired.
+func (v *OfflineValidator) VerifyActivationResponse(raw []byte, req *OfflineActivationRequest) (*OfflineLicenseData, error) {
+	if v.activationMaxAge >= 0 {
+		if age := v.clock.Now().Sub(req.CreatedAt); age > v.activationMaxAge {
+			return nil, fmt.Errorf("%w: created %s ago", ErrActivationRequestExpired, age.Round(time.Minute))
+		}
+	}
+	data, err := v.verify(raw, req.Fingerprint)
+	if err != nil {
+		return data, err
+	}
+	if data.LicenseKey != req.LicenseKey {
+		return data, fmt.Errorf("%w: response is for %q, requested %q", ErrLicenseKeyMismatch, data.LicenseKey, req.LicenseKey)
+	}
+	if len(data.Fingerprints) == 0 {
+		return data, fmt.Errorf("%w: response is not node-locked", ErrFingerprintMismatch)
+	}
+	if req.Nonce == "" || data.ActivationNonce != req.Nonce {
+		return data, fmt.Errorf("%w: nonce mismatch", ErrActivationResponseMismatch)
+	}
+	return data, nil
+}
+
+// AcceptActivationResponse verifies an activation response (see
+// VerifyActivationResponse) and, if valid, atomically stores it at destPath as
+// a node-locked offline license file usable by VerifyFile.
+func (v *OfflineValidator) AcceptActivationResponse(raw []byte, req *OfflineActivationRequest, destPath string) (*OfflineLicenseData, error) {
+	data, err := v.VerifyActivationResponse(raw, req)
+	if err != nil {
+		return data, err
+	}
+	if err := writeFileAtomic(destPath, raw); err != nil {
+		return nil, fmt.Errorf("store license file: %w", err)
+	}
+	return data, nil
+}
+
+// compactJSON returns raw with insignificant whitespace removed.
+func compactJSON(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,207 @@
+package cnwlicense_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/signer"
+)
+
+// signActivationResponse plays the role of the connected license server: it
+// issues a node-locked offline license for the request.
+func signActivationResponse(t *testing.T, s *signer.Signer, req *cnwlicense.OfflineActivationRequest, fingerprints ...string) []byte {
+	t.Helper()
+	if fingerprints == nil {
+		fingerprints = []string{req.Fingerprint}
+	}
+	return signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey:      req.LicenseKey,
+		Plan:            "enterprise",
+		ExpiresAt:       time.Now().Add(365 * 24 * time.Hour),
+		IssuedAt:        time.Now(),
+		Fingerprints:    fingerprints,
+		ActivationNonce: req.Nonce,
+	})
+}
+
+func TestOfflineActivationRequest_RoundTrip(t *testing.T) {
+	req, err := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "node-fp", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Hostname == "" || req.OS != runtime.GOOS || req.Nonce == "" || req.CreatedAt.IsZero() {
+		t.Errorf("expected machine details to be filled in, got %+v", req)
+	}
+
+	raw, err := req.Marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	parsed, err := cnwlicense.ParseOfflineActivationRequest(raw)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if parsed.LicenseKey != req.LicenseKey || parsed.Fingerprint != req.Fingerprint ||
+		parsed.Hostname != req.Hostname || parsed.Nonce != req.Nonce || !parsed.CreatedAt.Equal(req.CreatedAt) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", parsed, req)
+	}
+}
+
+func TestOfflineActivationRequest_GeneratesFingerprint(t *testing.T) {
+	t.Setenv("CNW_FINGERPRINT", "fp-from-env")
+	req, err := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Fingerprint != "fp-from-env" {
+		t.Errorf("expected generated fingerprint, got %s", req.Fingerprint)
+	}
+}
+
+func TestParseOfflineActivationRequest_Invalid(t *testing.T) {
+	req, _ := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "node-fp", time.Now())
+	raw, _ := req.Marshal()
+
+	tampered := bytes.Replace(raw, []byte("node-fp"), []byte("evil-fp"), 1)
+	missing, _ := (&cnwlicense.OfflineActivationRequest{LicenseKey: "CNW-TEST-1234"}).Marshal()
+
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"not json", []byte("not json")},
+		{"empty envelope", []byte(`{}`)},
+		{"tampered", tampered},
+		{"missing fields", missing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := cnwlicense.ParseOfflineActivationRequest(tt.raw); !errors.Is(err, cnwlicense.ErrActivationRequestInvalid) {
+				t.Errorf("expected ErrActivationRequestInvalid, got %v", err)
+			}
+		})
+	}
+
+	if _, err := cnwlicense.NewOfflineActivationRequest("", "node-fp", time.Now()); !errors.Is(err, cnwlicense.ErrActivationRequestInvalid) {
+		t.Errorf("expected ErrActivationRequestInvalid for empty license key, got %v", err)
+	}
+}
+
+func TestOfflineValidator_AcceptActivationResponse(t *testing.T) {
+	s := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+
+	req, _ := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "node-fp", time.Now())
+	dest := filepath.Join(t.TempDir(), "license.json")
+
+	data, err := v.AcceptActivationResponse(signActivationResponse(t, s, req), req, dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.Plan != "enterprise" {
+		t.Errorf("expected plan enterprise, got %s", data.Plan)
+	}
+
+	// The stored file is a regular node-locked offline license.
+	stored := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithMachineFingerprint("node-fp"))
+	if _, err := stored.VerifyFile(dest); err != nil {
+		t.Errorf("expected stored license to verify, got %v", err)
+	}
+}
+
+func TestOfflineValidator_VerifyActivationResponse_Rejects(t *testing.T) {
+	s := newSigner(t)
+	other := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+
+	req, _ := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "node-fp", time.Now())
+	otherKeyReq := *req
+	otherKeyReq.LicenseKey = "CNW-OTHER"
+	// A response issued for an earlier request from the same machine.
+	earlierReq, _ := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "node-fp", time.Now())
+
+	tests := []struct {
+		name    string
+		resp    []byte
+		wantErr error
+	}{
+		{"untrusted signer", signActivationResponse(t, other, req), cnwlicense.ErrSignatureInvalid},
+		{"other machine", signActivationResponse(t, s, req, "other-fp"), cnwlicense.ErrFingerprintMismatch},
+		{"not node-locked", signActivationResponse(t, s, req, []string{}...), cnwlicense.ErrFingerprintMismatch},
+		{"other license", signActivationResponse(t, s, &otherKeyReq), cnwlicense.ErrLicenseKeyMismatch},
+		{"other request", signActivationResponse(t, s, earlierReq), cnwlicense.ErrActivationResponseMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "license.json")
+			if _, err := v.AcceptActivationResponse(tt.resp, req, dest); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if _, err := os.Stat(dest); !os.IsNotExist(err) {
+				t.Error("expected rejected response not to be stored")
+			}
+		})
+	}
+}
+
+func TestOfflineValidator_VerifyActivationResponse_StaleRequest(t *testing.T) {
+	s := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithActivationRequestMaxAge(24*time.Hour),
+	)
+
+	req, _ := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "node-fp", time.Now().Add(-48*time.Hour))
+	if _, err := v.VerifyActivationResponse(signActivationResponse(t, s, req), req); !errors.Is(err, cnwlicense.ErrActivationRequestExpired) {
+		t.Errorf("expected ErrActivationRequestExpired, got %v", err)
+	}
+}
+
+func TestManager_OfflineActivation(t *testing.T) {
+	s := newSigner(t)
+	path := filepath.Join(t.TempDir(), "license.json")
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient("http://127.0.0.1:0", "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))),
+		cnwlicense.WithOfflineLicenseFile(path),
+	)
+
+	requestFile, err := mgr.NewOfflineActivationRequest("CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// On the connected machine: parse the request and issue a response.
+	req, err := cnwlicense.ParseOfflineActivationRequest(requestFile)
+	if err != nil {
+		t.Fatalf("parse request: %v", err)
+	}
+	if req.Fingerprint != "node-fp" {
+		t.Errorf("expected manager fingerprint in request, got %s", req.Fingerprint)
+	}
+	responseFile := signActivationResponse(t, s, req)
+
+	if _, err := mgr.AcceptOfflineActivation(requestFile, responseFile); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	info, err := mgr.ValidateOffline("CNW-TEST-1234")
+	if err != nil {
+		t.Fatalf("validate after activation: %v", err)
+	}
+	if info.Plan != "enterprise" {
+		t.Errorf("expected plan enterprise, got %s", info.Plan)
+	}
+
+	// A request created elsewhere cannot be accepted on this machine.
+	foreign, _ := cnwlicense.NewOfflineActivationRequest("CNW-TEST-1234", "other-fp", time.Now())
+	foreignFile, _ := foreign.Marshal()
+	if _, err := mgr.AcceptOfflineActivation(foreignFile, signActivationResponse(t, s, foreign)); !errors.Is(err, cnwlicense.ErrFingerprintMismatch) {
+		t.Errorf("expected ErrFingerprintMismatch for foreign request, got %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package cnwlicense
 
+import "time"
+
 // OfflineOption configures an OfflineValidator.
 type OfflineOption func(*OfflineValidator)
 
@@ -11,3 +13,49 @@ func WithTrustedPublicKey(base64PubKey string) OfflineOption {
 		v.trustedPublicKey = base64PubKey
 	}
 }
+
+// WithMachineFingerprint sets the fingerprint that node-locked licenses are checked
+// against. If unset, GenerateFingerprint() is used when a license is node-locked.
+func WithMachineFingerprint(fp string) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.fingerprint = fp
+	}
+}
+
+// WithTrustedKeys sets a keyring of trusted Ed25519 public keys, allowing the vendor
+// to rotate its signing key without breaking shipped binaries. When a license file
+// carries a key_id, only the key with that ID (and any keys without an ID) is tried;
+// otherwise every key is tried. Keys outside their validity window are rejected
+// with ErrKeyRetired. Can be combined with WithTrustedPublicKey, which adds a key
+// without an ID or validity window.
+func WithTrustedKeys(keys ...TrustedKey) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.trustedKeys = append(v.trustedKeys, keys...)
+	}
+}
+
+// WithMaxClockSkew sets how far in the future a license's issued_at may lie before
+// the license is rejected with ErrLicenseIssuedInFuture (default: 5m). A negative
+// value disables the check.
+func WithMaxClockSkew(d time.Duration) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.maxClockSkew = d
+	}
+}
+
+// WithActivationRequestMaxAge sets how old an offline activation request may be
+// when its response is verified (default: 7 days); older requests are rejected
+// with ErrActivationRequestExpired. A negative value disables the check.
+func WithActivationRequestMaxAge(d time.Duration) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.activationMaxAge = d
+	}
+}
+
+// WithValidatorClock sets the Clock used for expiry, not-before, key validity and
+// revocation checks (default: the system clock).
+func WithValidatorClock(c Clock) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.clock = c
+	}
+}
@@ -1,29 +1,65 @@
-package cnwlicense
+package cnwlicense_test
 
 import (
-	"crypto/ed25519"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/signer"
 )
 
-// signLicenseData mimics the server's crypto.SignJSON behavior:
-// marshal to JSON, then sign the raw bytes.
-func signLicenseData(priv ed25519.PrivateKey, data OfflineLicenseData) (json.RawMessage, []byte) {
-	raw, _ := json.Marshal(data)
-	sig := ed25519.Sign(priv, raw)
-	return json.RawMessage(raw), sig
+// newSigner returns a signer for a freshly generated key.
+func newSigner(t *testing.T, opts ...signer.Option) *signer.Signer {
+	t.Helper()
+	s, err := signer.Generate(opts...)
+	if err != nil {
+		t.Fatalf("generate signing key: %v", err)
+	}
+	return s
+}
+
+// signLicense signs data with s, like the license server does, and returns
+// the license file.
+func signLicense(t *testing.T, s *signer.Signer, data cnwlicense.OfflineLicenseData) []byte {
+	t.Helper()
+	file, err := s.Sign(data)
+	if err != nil {
+		t.Fatalf("sign license: %v", err)
+	}
+	return marshalFile(t, file)
+}
+
+// signLicenseWithKeyID signs data with s but names keyID in the envelope
+// instead of the signer's own key ID.
+func signLicenseWithKeyID(t *testing.T, s *signer.Signer, data cnwlicense.OfflineLicenseData, keyID string) []byte {
+	t.Helper()
+	file, err := s.Sign(data)
+	if err != nil {
+		t.Fatalf("sign license: %v", err)
+	}
+	file.KeyID = keyID
+	return marshalFile(t, file)
+}
+
+// marshalFile encodes a license file envelope, e.g. after a test altered it.
+func marshalFile(t *testing.T, file *cnwlicense.OfflineLicenseFile) []byte {
+	t.Helper()
+	raw, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("marshal license file: %v", err)
+	}
+	return raw
 }
 
 func TestOfflineValidator_Verify_Success(t *testing.T) {
-	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	s := newSigner(t)
 
-	data := OfflineLicenseData{
+	data := cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-TEST-1234",
 		CompanyID:  "comp-001",
 		AppID:      "app-001",
@@ -33,17 +69,8 @@ func TestOfflineValidator_Verify_Success(t *testing.T) {
 		IssuedAt:   time.Now(),
 	}
 
-	rawLicense, sig := signLicenseData(priv, data)
-
-	file := OfflineLicenseFile{
-		License:   rawLicense,
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: base64.StdEncoding.EncodeToString(pub),
-	}
-	fileJSON, _ := json.Marshal(file)
-
-	v := NewOfflineValidator()
-	result, err := v.Verify(fileJSON)
+	v := cnwlicense.NewOfflineValidator()
+	result, err := v.Verify(signLicense(t, s, data))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -56,25 +83,20 @@ func TestOfflineValidator_Verify_Success(t *testing.T) {
 }
 
 func TestOfflineValidator_Verify_WithTrustedKey(t *testing.T) {
-	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	s := newSigner(t)
 
-	data := OfflineLicenseData{
+	file, err := s.Sign(cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-TRUSTED",
 		ExpiresAt:  time.Now().Add(24 * time.Hour),
 		IssuedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
+	file.PublicKey = "" // no embedded key
 
-	rawLicense, sig := signLicenseData(priv, data)
-
-	file := OfflineLicenseFile{
-		License:   rawLicense,
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: "", // no embedded key
-	}
-	fileJSON, _ := json.Marshal(file)
-
-	v := NewOfflineValidator(WithTrustedPublicKey(base64.StdEncoding.EncodeToString(pub)))
-	result, err := v.Verify(fileJSON)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+	result, err := v.Verify(marshalFile(t, file))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -84,27 +106,22 @@ func TestOfflineValidator_Verify_WithTrustedKey(t *testing.T) {
 }
 
 func TestOfflineValidator_Verify_TrustedKeyOverridesEmbedded(t *testing.T) {
-	trustedPub, trustedPriv, _ := ed25519.GenerateKey(rand.Reader)
-	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	trusted := newSigner(t)
+	other := newSigner(t)
 
-	data := OfflineLicenseData{
+	file, err := trusted.Sign(cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-OVERRIDE",
 		ExpiresAt:  time.Now().Add(24 * time.Hour),
 		IssuedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	rawLicense, sig := signLicenseData(trustedPriv, data)
-
-	file := OfflineLicenseFile{
-		License:   rawLicense,
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: base64.StdEncoding.EncodeToString(otherPub), // wrong embedded key
-	}
-	fileJSON, _ := json.Marshal(file)
+	file.PublicKey = other.PublicKey() // wrong embedded key
 
 	// Trusted key should override the embedded one
-	v := NewOfflineValidator(WithTrustedPublicKey(base64.StdEncoding.EncodeToString(trustedPub)))
-	result, err := v.Verify(fileJSON)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(trusted.PublicKey()))
+	result, err := v.Verify(marshalFile(t, file))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -114,26 +131,17 @@ func TestOfflineValidator_Verify_TrustedKeyOverridesEmbedded(t *testing.T) {
 }
 
 func TestOfflineValidator_Verify_Expired(t *testing.T) {
-	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	s := newSigner(t)
 
-	data := OfflineLicenseData{
+	data := cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-EXPIRED",
 		ExpiresAt:  time.Now().Add(-24 * time.Hour), // expired yesterday
 		IssuedAt:   time.Now().Add(-48 * time.Hour),
 	}
 
-	rawLicense, sig := signLicenseData(priv, data)
-
-	file := OfflineLicenseFile{
-		License:   rawLicense,
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: base64.StdEncoding.EncodeToString(pub),
-	}
-	fileJSON, _ := json.Marshal(file)
-
-	v := NewOfflineValidator()
-	result, err := v.Verify(fileJSON)
-	if !errors.Is(err, ErrLicenseExpired) {
+	v := cnwlicense.NewOfflineValidator()
+	result, err := v.Verify(signLicense(t, s, data))
+	if !errors.Is(err, cnwlicense.ErrLicenseExpired) {
 		t.Errorf("expected ErrLicenseExpired, got %v", err)
 	}
 	// Data should still be returned for expired licenses
@@ -146,107 +154,88 @@ func TestOfflineValidator_Verify_Expired(t *testing.T) {
 }
 
 func TestOfflineValidator_Verify_TamperedData(t *testing.T) {
-	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	s := newSigner(t)
 
-	data := OfflineLicenseData{
+	data := cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-ORIGINAL",
 		ExpiresAt:  time.Now().Add(24 * time.Hour),
 		IssuedAt:   time.Now(),
 	}
-
-	_, sig := signLicenseData(priv, data)
+	file, err := s.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Tamper: change the license key
 	data.LicenseKey = "CNW-TAMPERED"
-	tamperedRaw, _ := json.Marshal(data)
+	file.License, _ = json.Marshal(data)
 
-	file := OfflineLicenseFile{
-		License:   json.RawMessage(tamperedRaw),
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: base64.StdEncoding.EncodeToString(pub),
-	}
-	fileJSON, _ := json.Marshal(file)
-
-	v := NewOfflineValidator()
-	_, err := v.Verify(fileJSON)
-	if !errors.Is(err, ErrSignatureInvalid) {
+	v := cnwlicense.NewOfflineValidator()
+	_, err = v.Verify(marshalFile(t, file))
+	if !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
 		t.Errorf("expected ErrSignatureInvalid, got %v", err)
 	}
 }
 
 func TestOfflineValidator_Verify_WrongKey(t *testing.T) {
-	_, priv, _ := ed25519.GenerateKey(rand.Reader)
-	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	s := newSigner(t)
+	other := newSigner(t)
 
-	data := OfflineLicenseData{
+	file, err := s.Sign(cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-WRONGKEY",
 		ExpiresAt:  time.Now().Add(24 * time.Hour),
 		IssuedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
+	file.PublicKey = other.PublicKey() // wrong key
 
-	rawLicense, sig := signLicenseData(priv, data)
-
-	file := OfflineLicenseFile{
-		License:   rawLicense,
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: base64.StdEncoding.EncodeToString(otherPub), // wrong key
-	}
-	fileJSON, _ := json.Marshal(file)
-
-	v := NewOfflineValidator()
-	_, err := v.Verify(fileJSON)
-	if !errors.Is(err, ErrSignatureInvalid) {
+	v := cnwlicense.NewOfflineValidator()
+	_, err = v.Verify(marshalFile(t, file))
+	if !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
 		t.Errorf("expected ErrSignatureInvalid, got %v", err)
 	}
 }
 
 func TestOfflineValidator_Verify_InvalidJSON(t *testing.T) {
-	v := NewOfflineValidator()
+	v := cnwlicense.NewOfflineValidator()
 	_, err := v.Verify([]byte("not json"))
-	if !errors.Is(err, ErrLicenseFileInvalid) {
+	if !errors.Is(err, cnwlicense.ErrLicenseFileInvalid) {
 		t.Errorf("expected ErrLicenseFileInvalid, got %v", err)
 	}
 }
 
 func TestOfflineValidator_Verify_MissingFields(t *testing.T) {
-	v := NewOfflineValidator()
+	v := cnwlicense.NewOfflineValidator()
 	_, err := v.Verify([]byte(`{"license": {}, "signature": ""}`))
-	if !errors.Is(err, ErrLicenseFileInvalid) {
+	if !errors.Is(err, cnwlicense.ErrLicenseFileInvalid) {
 		t.Errorf("expected ErrLicenseFileInvalid, got %v", err)
 	}
 }
 
 func TestOfflineValidator_Verify_NoPublicKey(t *testing.T) {
-	v := NewOfflineValidator()
+	v := cnwlicense.NewOfflineValidator()
 	_, err := v.Verify([]byte(`{"license": {"license_key":"test"}, "signature": "abc"}`))
-	if !errors.Is(err, ErrPublicKeyInvalid) {
+	if !errors.Is(err, cnwlicense.ErrPublicKeyInvalid) {
 		t.Errorf("expected ErrPublicKeyInvalid, got %v", err)
 	}
 }
 
 func TestOfflineValidator_VerifyFile(t *testing.T) {
-	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	s := newSigner(t)
 
-	data := OfflineLicenseData{
+	data := cnwlicense.OfflineLicenseData{
 		LicenseKey: "CNW-FILE-TEST",
 		ExpiresAt:  time.Now().Add(24 * time.Hour),
 		IssuedAt:   time.Now(),
 	}
 
-	rawLicense, sig := signLicenseData(priv, data)
-
-	file := OfflineLicenseFile{
-		License:   rawLicense,
-		Signature: base64.StdEncoding.EncodeToString(sig),
-		PublicKey: base64.StdEncoding.EncodeToString(pub),
-	}
-	fileJSON, _ := json.Marshal(file)
-
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "license.json")
-	os.WriteFile(filePath, fileJSON, 0644)
+	os.WriteFile(filePath, signLicense(t, s, data), 0644)
 
-	v := NewOfflineValidator()
+	v := cnwlicense.NewOfflineValidator()
 	result, err := v.VerifyFile(filePath)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -257,9 +246,229 @@ func TestOfflineValidator_VerifyFile(t *testing.T) {
 }
 
 func TestOfflineValidator_VerifyFile_NotFound(t *testing.T) {
-	v := NewOfflineValidator()
+	v := cnwlicense.NewOfflineValidator()
 	_, err := v.VerifyFile("/nonexistent/license.json")
 	if err == nil {
 		t.Fatal("expected error for missing file")
 	}
 }
+
+func TestOfflineValidator_Verify_KeyRotation(t *testing.T) {
+	oldKey := newSigner(t, signer.WithKeyID("2024"))
+	newKey := newSigner(t, signer.WithKeyID("2025"))
+
+	data := cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-ROTATE",
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		IssuedAt:   time.Now(),
+	}
+
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedKeys(oldKey.TrustedKey(), newKey.TrustedKey()))
+
+	tests := []struct {
+		name   string
+		file   []byte
+		wantID string
+	}{
+		{"old key by id", signLicense(t, oldKey, data), "2024"},
+		{"new key by id", signLicense(t, newKey, data), "2025"},
+		{"old key without id", signLicenseWithKeyID(t, oldKey, data, ""), "2024"},
+		{"new key without id", signLicenseWithKeyID(t, newKey, data, ""), "2025"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := v.Verify(tt.file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.VerifiedKeyID != tt.wantID {
+				t.Errorf("expected key id %q, got %q", tt.wantID, result.VerifiedKeyID)
+			}
+		})
+	}
+
+	// A key_id pointing at the wrong key does not fall through to the others.
+	_, err := v.Verify(signLicenseWithKeyID(t, oldKey, data, "2025"))
+	if !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
+		t.Errorf("expected ErrSignatureInvalid for mismatched key id, got %v", err)
+	}
+}
+
+func TestOfflineValidator_Verify_UnknownKeyID(t *testing.T) {
+	s := newSigner(t, signer.WithKeyID("1999"))
+	data := cnwlicense.OfflineLicenseData{LicenseKey: "CNW-UNKNOWN", ExpiresAt: time.Now().Add(time.Hour)}
+
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedKeys(
+		cnwlicense.TrustedKey{ID: "2025", PublicKey: s.PublicKey()},
+	))
+	_, err := v.Verify(signLicense(t, s, data))
+	if !errors.Is(err, cnwlicense.ErrPublicKeyInvalid) {
+		t.Errorf("expected ErrPublicKeyInvalid for unknown key id, got %v", err)
+	}
+}
+
+func TestOfflineValidator_Verify_KeyValidityWindow(t *testing.T) {
+	retired := newSigner(t, signer.WithKeyID("retired"))
+	future := newSigner(t, signer.WithKeyID("future"))
+	data := cnwlicense.OfflineLicenseData{LicenseKey: "CNW-WINDOW", ExpiresAt: time.Now().Add(time.Hour)}
+
+	retiredKey := retired.TrustedKey()
+	retiredKey.NotAfter = time.Now().Add(-time.Hour)
+	futureKey := future.TrustedKey()
+	futureKey.NotBefore = time.Now().Add(time.Hour)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedKeys(retiredKey, futureKey))
+
+	for _, file := range [][]byte{
+		signLicense(t, retired, data),
+		signLicenseWithKeyID(t, retired, data, ""),
+		signLicense(t, future, data),
+	} {
+		if _, err := v.Verify(file); !errors.Is(err, cnwlicense.ErrKeyRetired) {
+			t.Errorf("expected ErrKeyRetired, got %v", err)
+		}
+	}
+}
+
+func TestOfflineValidator_Verify_KeyringIgnoresEmbeddedKey(t *testing.T) {
+	trusted := newSigner(t, signer.WithKeyID("2025"))
+	attacker := newSigner(t)
+
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedKeys(trusted.TrustedKey()))
+	forged := signLicense(t, attacker, cnwlicense.OfflineLicenseData{LicenseKey: "CNW-FORGED"})
+	if _, err := v.Verify(forged); !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
+		t.Errorf("expected ErrSignatureInvalid for embedded key, got %v", err)
+	}
+}
+
+func TestOfflineValidator_Verify_NodeLocked(t *testing.T) {
+	s := newSigner(t)
+
+	file := signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey:   "CNW-NODE",
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+		IssuedAt:     time.Now(),
+		Fingerprints: []string{"fp-node-1", "fp-node-2"},
+	})
+
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithMachineFingerprint("fp-node-2"))
+	result, err := v.Verify(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Fingerprints) != 2 {
+		t.Errorf("expected fingerprints in license data, got %v", result.Fingerprints)
+	}
+
+	v = cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithMachineFingerprint("fp-other"))
+	result, err = v.Verify(file)
+	if !errors.Is(err, cnwlicense.ErrFingerprintMismatch) {
+		t.Errorf("expected ErrFingerprintMismatch, got %v", err)
+	}
+	if result == nil || result.LicenseKey != "CNW-NODE" {
+		t.Error("expected data to be returned alongside ErrFingerprintMismatch")
+	}
+}
+
+func TestOfflineValidator_Verify_NodeLockedGeneratedFingerprint(t *testing.T) {
+	t.Setenv("CNW_FINGERPRINT", "fp-from-env")
+	s := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+
+	data := cnwlicense.OfflineLicenseData{
+		LicenseKey:   "CNW-NODE",
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+		Fingerprints: []string{"fp-from-env"},
+	}
+	if _, err := v.Verify(signLicense(t, s, data)); err != nil {
+		t.Errorf("expected GenerateFingerprint() to match, got %v", err)
+	}
+
+	data.Fingerprints = []string{"fp-elsewhere"}
+	if _, err := v.Verify(signLicense(t, s, data)); !errors.Is(err, cnwlicense.ErrFingerprintMismatch) {
+		t.Errorf("expected ErrFingerprintMismatch, got %v", err)
+	}
+}
+
+func TestOfflineValidator_Verify_NotNodeLockedOmitsField(t *testing.T) {
+	// Licenses without fingerprints must marshal exactly as before, so that
+	// signatures produced by existing servers keep verifying.
+	raw, _ := json.Marshal(cnwlicense.OfflineLicenseData{LicenseKey: "CNW-ANY"})
+	var fields map[string]interface{}
+	json.Unmarshal(raw, &fields)
+	if _, ok := fields["fingerprints"]; ok {
+		t.Errorf("expected fingerprints to be omitted when empty, got %s", raw)
+	}
+}
+
+func TestOfflineValidator_Verify_NotBefore(t *testing.T) {
+	s := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+
+	future := time.Now().Add(30 * 24 * time.Hour)
+	data, err := v.Verify(signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-RENEWAL",
+		Plan:       "enterprise",
+		ExpiresAt:  time.Now().Add(365 * 24 * time.Hour),
+		IssuedAt:   time.Now(),
+		NotBefore:  &future,
+	}))
+	if !errors.Is(err, cnwlicense.ErrLicenseNotYetValid) {
+		t.Fatalf("expected ErrLicenseNotYetValid, got %v", err)
+	}
+	if errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Error("not-yet-valid must be distinguishable from expired")
+	}
+	// Data is returned alongside the error, as for expired licenses.
+	if data == nil || data.Plan != "enterprise" {
+		t.Errorf("expected data alongside error, got %+v", data)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if _, err := v.Verify(signLicense(t, s, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-RENEWAL",
+		ExpiresAt:  time.Now().Add(365 * 24 * time.Hour),
+		IssuedAt:   time.Now().Add(-2 * time.Hour),
+		NotBefore:  &past,
+	})); err != nil {
+		t.Errorf("expected license past its not_before to verify, got %v", err)
+	}
+}
+
+func TestOfflineValidator_Verify_IssuedInFuture(t *testing.T) {
+	s := newSigner(t)
+
+	file := func(issuedAt time.Time) []byte {
+		return signLicense(t, s, cnwlicense.OfflineLicenseData{
+			LicenseKey: "CNW-SKEW",
+			ExpiresAt:  time.Now().Add(365 * 24 * time.Hour),
+			IssuedAt:   issuedAt,
+		})
+	}
+
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+	if _, err := v.Verify(file(time.Now().Add(time.Minute))); err != nil {
+		t.Errorf("expected issued_at within default skew to verify, got %v", err)
+	}
+	if _, err := v.Verify(file(time.Now().Add(time.Hour))); !errors.Is(err, cnwlicense.ErrLicenseIssuedInFuture) {
+		t.Errorf("expected ErrLicenseIssuedInFuture, got %v", err)
+	}
+
+	v = cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithMaxClockSkew(2*time.Hour))
+	if _, err := v.Verify(file(time.Now().Add(time.Hour))); err != nil {
+		t.Errorf("expected issued_at within custom skew to verify, got %v", err)
+	}
+
+	v = cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithMaxClockSkew(-1))
+	if _, err := v.Verify(file(time.Now().Add(24 * time.Hour))); err != nil {
+		t.Errorf("expected check to be disabled, got %v", err)
+	}
+}
+
+func TestOfflineLicenseData_NotBeforeOmitted(t *testing.T) {
+	// Licenses without not_before must serialize exactly as before so existing
+	// signatures remain valid.
+	raw, _ := json.Marshal(cnwlicense.OfflineLicenseData{LicenseKey: "CNW-TEST"})
+	if strings.Contains(string(raw), "not_before") {
+		t.Errorf("expected not_before to be omitted, got %s", raw)
+	}
+}
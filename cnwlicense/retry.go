@@ -0,0 +1,114 @@
+package cnwlicense
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// RetryPolicy controls how OnlineClient retries failed requests.
+// The zero value disables retries (a single attempt is made).
+//
+// Only transient failures are retried: transport errors (connection refused,
+// resets, per-attempt timeouts) and server errors whose HTTP status is listed in
+// RetryableStatusCodes. Explicit rejections that map to a sentinel error
+// (e.g. ErrActivationLimit, ErrLicenseNotFound) are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. Each subsequent retry
+	// doubles the delay, up to MaxDelay.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between attempts. 0 means no cap.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction (0..1) of each delay that is randomized, so that
+	// many clients failing at once do not retry in lockstep.
+	Jitter float64
+
+	// RetryableStatusCodes lists the HTTP status codes that are retried.
+	// If nil, 502, 503 and 504 are retried.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns a policy suitable for most services:
+// 3 attempts, 200ms base delay doubling up to 5s, with 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+var defaultRetryableStatusCodes = []int{502, 503, 504}
+
+// attempts returns the total number of attempts allowed by the policy.
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay before the given retry (1 = first retry).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if j := min(max(p.Jitter, 0), 1); j > 0 && d > 0 {
+		// Subtract a random portion so the delay never exceeds MaxDelay.
+		d -= time.Duration(rand.Float64() * j * float64(d))
+	}
+	return d
+}
+
+// retryable reports whether err is a transient failure worth retrying.
+func (p RetryPolicy) retryable(err error) bool {
+	// Sentinel-mapped errors are explicit rejections from the server.
+	var me *mappedError
+	if errors.As(err, &me) {
+		return false
+	}
+	var se *ServerError
+	if errors.As(err, &se) {
+		codes := p.RetryableStatusCodes
+		if codes == nil {
+			codes = defaultRetryableStatusCodes
+		}
+		return slices.Contains(codes, se.StatusCode)
+	}
+	// Transport-level failures are returned by http.Client as *url.Error.
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+// It returns an error without sleeping if ctx's deadline would expire before d elapses.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,293 @@
+package cnwlicense
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry is a retry policy with tiny delays for tests.
+func fastRetry(attempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: attempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}
+}
+
+func TestOnlineClient_Retry_TransientThenSuccess(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ValidateResponse{Valid: true})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRetry(fastRetry(3)))
+	resp, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Error("expected valid=true")
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestOnlineClient_Retry_ExhaustsAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRetry(fastRetry(4)))
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	var se *ServerError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 ServerError, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 4 {
+		t.Errorf("expected 4 attempts, got %d", got)
+	}
+}
+
+func TestOnlineClient_Retry_NoRetryOnActivationLimit(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{
+				"code":    "ACTIVATION_LIMIT",
+				"message": "activation limit reached",
+			},
+		})
+	}))
+	defer server.Close()
+
+	// Even if 409 is configured as retryable, sentinel-mapped errors are final.
+	policy := fastRetry(5)
+	policy.RetryableStatusCodes = []int{http.StatusConflict}
+	client := NewOnlineClient(server.URL, "test-key", WithRetry(policy))
+	_, err := client.Activate(context.Background(), ActivateRequest{
+		LicenseKey:  "CNW-TEST-1234",
+		Fingerprint: "abc123",
+		Hostname:    "node-1",
+	})
+	if !errors.Is(err, ErrActivationLimit) {
+		t.Errorf("expected ErrActivationLimit, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestOnlineClient_Retry_StatusNotRetryable(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRetry(fastRetry(3)))
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 attempt for non-retryable 500, got %d", got)
+	}
+
+	// Explicitly listing 500 makes it retryable.
+	atomic.StoreInt32(&calls, 0)
+	policy := fastRetry(3)
+	policy.RetryableStatusCodes = []int{http.StatusInternalServerError}
+	client = NewOnlineClient(server.URL, "test-key", WithRetry(policy))
+	client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("expected 3 attempts for retryable 500, got %d", got)
+	}
+}
+
+func TestOnlineClient_Retry_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close() // connection refused from now on
+
+	client := NewOnlineClient(url, "test-key", WithRetry(fastRetry(3)))
+	start := time.Now()
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err == nil {
+		t.Fatal("expected network error")
+	}
+	if time.Since(start) < 2*time.Millisecond {
+		t.Error("expected backoff delays between attempts")
+	}
+}
+
+func TestOnlineClient_Retry_RespectsContextDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRetry(RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Validate(ctx, ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected retry loop to give up before the deadline, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+	// The last server error is returned rather than a bare context error.
+	var se *ServerError
+	if !errors.As(err, &se) {
+		t.Errorf("expected ServerError, got %T: %v", err, err)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(3)
+		if d < 200*time.Millisecond || d > 400*time.Millisecond {
+			t.Fatalf("jittered backoff %v outside [200ms, 400ms]", d)
+		}
+	}
+}
+
+func TestOnlineClient_RateLimitRetry_WaitsAndSucceeds(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ValidateResponse{Valid: true})
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRateLimitRetry(2, 5*time.Second))
+	var slept []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	resp, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Error("expected valid=true")
+	}
+	if len(slept) != 1 || slept[0] != time.Second {
+		t.Errorf("expected client to honor Retry-After of 1s, slept %v", slept)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestOnlineClient_RateLimitRetry_ExceedsMaxWait(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRateLimitRetry(3, 5*time.Second))
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 attempt when Retry-After exceeds max wait, got %d", got)
+	}
+}
+
+func TestOnlineClient_RateLimitRetry_RespectsContextDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewOnlineClient(server.URL, "test-key", WithRateLimitRetry(3, 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Validate(ctx, ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestOnlineClient_RateLimited_NotRetriedByDefault(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	// The generic retry policy does not retry 429s; only WithRateLimitRetry does.
+	client := NewOnlineClient(server.URL, "test-key", WithRetry(fastRetry(3)))
+	_, err := client.Validate(context.Background(), ValidateRequest{LicenseKey: "CNW-TEST-1234"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
@@ -0,0 +1,124 @@
+package cnwlicense
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RevocationListFile is the signed envelope of a revocation list. It uses the same
+// Ed25519 format as OfflineLicenseFile: the list is kept as raw JSON and the
+// signature covers its exact bytes.
+type RevocationListFile struct {
+	RevocationList json.RawMessage `json:"revocation_list"`
+	Signature      string          `json:"signature"`
+	PublicKey      string          `json:"public_key"`
+	KeyID          string          `json:"key_id,omitempty"`
+}
+
+// RevocationList lists offline licenses that have been revoked.
+type RevocationList struct {
+	IssuedAt time.Time        `json:"issued_at"`
+	Revoked  []RevokedLicense `json:"revoked"`
+}
+
+// RevokedLicense is a single revocation entry.
+type RevokedLicense struct {
+	LicenseKey string    `json:"license_key"`
+	RevokedAt  time.Time `json:"revoked_at"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// WithRevocationList loads a signed revocation list (raw JSON) into the validator.
+// The list is verified with the same trusted keys as license files, after all
+// options are applied. If it cannot be loaded, Verify fails with the load error
+// until a later LoadRevocationList succeeds.
+func WithRevocationList(raw []byte) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.pendingRevocations = append(v.pendingRevocations, func() error {
+			return v.LoadRevocationList(raw)
+		})
+	}
+}
+
+// WithRevocationListFile is like WithRevocationList but reads the list from a file.
+func WithRevocationListFile(path string) OfflineOption {
+	return func(v *OfflineValidator) {
+		v.pendingRevocations = append(v.pendingRevocations, func() error {
+			return v.LoadRevocationListFile(path)
+		})
+	}
+}
+
+// LoadRevocationListFile reads a signed revocation list from disk and loads it.
+func (v *OfflineValidator) LoadRevocationListFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read revocation list: %w", err)
+	}
+	return v.LoadRevocationList(raw)
+}
+
+// LoadRevocationList verifies a signed revocation list and replaces the currently
+// loaded one. A list issued before the currently loaded one is rejected, so an
+// old list cannot be used to un-revoke a license. The list must be signed by a
+// trusted key (see WithTrustedPublicKey and WithTrustedKeys); the key embedded in
+// the envelope is never used. A successful load clears an error left by
+// WithRevocationList or WithRevocationListFile. Safe for concurrent use with Verify.
+func (v *OfflineValidator) LoadRevocationList(raw []byte) error {
+	if len(v.keyring()) == 0 {
+		return fmt.Errorf("%w: %w: no trusted key configured", ErrRevocationListInvalid, ErrPublicKeyInvalid)
+	}
+
+	var file RevocationListFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("%w: %v", ErrRevocationListInvalid, err)
+	}
+	if len(file.RevocationList) == 0 || file.Signature == "" {
+		return ErrRevocationListInvalid
+	}
+
+	if _, err := v.verifySignature(file.RevocationList, file.Signature, file.PublicKey, file.KeyID); err != nil {
+		return fmt.Errorf("%w: %w", ErrRevocationListInvalid, err)
+	}
+
+	var list RevocationList
+	if err := json.Unmarshal(file.RevocationList, &list); err != nil {
+		return fmt.Errorf("%w: parse revocation list: %v", ErrRevocationListInvalid, err)
+	}
+
+	revoked := make(map[string]RevokedLicense, len(list.Revoked))
+	for _, r := range list.Revoked {
+		revoked[r.LicenseKey] = r
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if list.IssuedAt.Before(v.revocationsIssuedAt) {
+		return fmt.Errorf("%w: issued %s, older than loaded list issued %s", ErrRevocationListInvalid,
+			list.IssuedAt.Format(time.RFC3339), v.revocationsIssuedAt.Format(time.RFC3339))
+	}
+	v.revocations = revoked
+	v.revocationsIssuedAt = list.IssuedAt
+	v.revocationErr = nil
+	return nil
+}
+
+// checkRevoked returns ErrLicenseRevoked if licenseKey is on the loaded
+// revocation list with a revocation time at or before now.
+func (v *OfflineValidator) checkRevoked(licenseKey string, now time.Time) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.revocationErr != nil {
+		return v.revocationErr
+	}
+	r, ok := v.revocations[licenseKey]
+	if !ok || r.RevokedAt.After(now) {
+		return nil
+	}
+	if r.Reason != "" {
+		return fmt.Errorf("%w: %s", ErrLicenseRevoked, r.Reason)
+	}
+	return ErrLicenseRevoked
+}
@@ -0,0 +1,149 @@
+package cnwlicense_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense/signer"
+)
+
+// signRevocations signs list with s and returns the revocation list file.
+func signRevocations(t *testing.T, s *signer.Signer, list cnwlicense.RevocationList) []byte {
+	t.Helper()
+	file, err := s.SignRevocationList(list)
+	if err != nil {
+		t.Fatalf("sign revocation list: %v", err)
+	}
+	raw, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("marshal revocation list: %v", err)
+	}
+	return raw
+}
+
+func TestOfflineValidator_Verify_Revoked(t *testing.T) {
+	s := newSigner(t)
+
+	list := signRevocations(t, s, cnwlicense.RevocationList{
+		IssuedAt: time.Now(),
+		Revoked: []cnwlicense.RevokedLicense{
+			{LicenseKey: "CNW-REVOKED", RevokedAt: time.Now().Add(-time.Hour), Reason: "contract terminated"},
+			{LicenseKey: "CNW-LATER", RevokedAt: time.Now().Add(24 * time.Hour)},
+		},
+	})
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()), cnwlicense.WithRevocationList(list))
+
+	data := cnwlicense.OfflineLicenseData{ExpiresAt: time.Now().Add(24 * time.Hour), IssuedAt: time.Now()}
+
+	data.LicenseKey = "CNW-REVOKED"
+	result, err := v.Verify(signLicense(t, s, data))
+	if !errors.Is(err, cnwlicense.ErrLicenseRevoked) {
+		t.Fatalf("expected ErrLicenseRevoked, got %v", err)
+	}
+	if result == nil || result.LicenseKey != "CNW-REVOKED" {
+		t.Error("expected data to be returned alongside ErrLicenseRevoked")
+	}
+
+	// Revocation scheduled in the future is not yet effective.
+	data.LicenseKey = "CNW-LATER"
+	if _, err := v.Verify(signLicense(t, s, data)); err != nil {
+		t.Errorf("expected future revocation to be ignored, got %v", err)
+	}
+
+	data.LicenseKey = "CNW-GOOD"
+	if _, err := v.Verify(signLicense(t, s, data)); err != nil {
+		t.Errorf("expected unrevoked license to verify, got %v", err)
+	}
+}
+
+func TestOfflineValidator_LoadRevocationList_Invalid(t *testing.T) {
+	s := newSigner(t)
+	attacker := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+
+	forged := signRevocations(t, attacker, cnwlicense.RevocationList{IssuedAt: time.Now()})
+	if err := v.LoadRevocationList(forged); !errors.Is(err, cnwlicense.ErrRevocationListInvalid) || !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
+		t.Errorf("expected ErrRevocationListInvalid wrapping ErrSignatureInvalid, got %v", err)
+	}
+	if err := v.LoadRevocationList([]byte("not json")); !errors.Is(err, cnwlicense.ErrRevocationListInvalid) {
+		t.Errorf("expected ErrRevocationListInvalid, got %v", err)
+	}
+}
+
+func TestOfflineValidator_LoadRevocationList_RequiresTrustedKey(t *testing.T) {
+	// The signed envelope embeds the signer's public key.
+	selfSigned := signRevocations(t, newSigner(t), cnwlicense.RevocationList{IssuedAt: time.Now()})
+
+	// The key embedded in the list proves nothing about who issued it.
+	v := cnwlicense.NewOfflineValidator()
+	if err := v.LoadRevocationList(selfSigned); !errors.Is(err, cnwlicense.ErrRevocationListInvalid) || !errors.Is(err, cnwlicense.ErrPublicKeyInvalid) {
+		t.Errorf("expected ErrRevocationListInvalid wrapping ErrPublicKeyInvalid, got %v", err)
+	}
+}
+
+func TestOfflineValidator_LoadRevocationList_RejectsOlderList(t *testing.T) {
+	s := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(s.PublicKey()))
+
+	newer := signRevocations(t, s, cnwlicense.RevocationList{
+		IssuedAt: time.Now(),
+		Revoked:  []cnwlicense.RevokedLicense{{LicenseKey: "CNW-REVOKED", RevokedAt: time.Now().Add(-time.Hour)}},
+	})
+	older := signRevocations(t, s, cnwlicense.RevocationList{IssuedAt: time.Now().Add(-24 * time.Hour)})
+
+	if err := v.LoadRevocationList(newer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := v.LoadRevocationList(older); !errors.Is(err, cnwlicense.ErrRevocationListInvalid) {
+		t.Errorf("expected older list to be rejected, got %v", err)
+	}
+
+	data := cnwlicense.OfflineLicenseData{LicenseKey: "CNW-REVOKED", ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := v.Verify(signLicense(t, s, data)); !errors.Is(err, cnwlicense.ErrLicenseRevoked) {
+		t.Errorf("expected revocation to survive older list, got %v", err)
+	}
+}
+
+func TestOfflineValidator_WithRevocationListFile(t *testing.T) {
+	s := newSigner(t)
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	os.WriteFile(path, signRevocations(t, s, cnwlicense.RevocationList{
+		IssuedAt: time.Now(),
+		Revoked:  []cnwlicense.RevokedLicense{{LicenseKey: "CNW-REVOKED", RevokedAt: time.Now().Add(-time.Hour)}},
+	}), 0644)
+
+	// The revocation list option may come before the key option.
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithRevocationListFile(path),
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+	)
+	data := cnwlicense.OfflineLicenseData{LicenseKey: "CNW-REVOKED", ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := v.Verify(signLicense(t, s, data)); !errors.Is(err, cnwlicense.ErrLicenseRevoked) {
+		t.Errorf("expected ErrLicenseRevoked, got %v", err)
+	}
+}
+
+func TestOfflineValidator_WithRevocationListFile_FailsClosed(t *testing.T) {
+	s := newSigner(t)
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithRevocationListFile("/nonexistent/revocations.json"),
+	)
+	data := cnwlicense.OfflineLicenseData{LicenseKey: "CNW-GOOD", ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := v.Verify(signLicense(t, s, data)); err == nil {
+		t.Error("expected Verify to fail when the configured revocation list cannot be loaded")
+	}
+
+	// Loading a list later recovers from the failed option.
+	if err := v.LoadRevocationList(signRevocations(t, s, cnwlicense.RevocationList{IssuedAt: time.Now()})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := v.Verify(signLicense(t, s, data)); err != nil {
+		t.Errorf("expected Verify to succeed after a successful load, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package signer issues Ed25519-signed offline license files and revocation
+// lists for use with cnwlicense.OfflineValidator.
+//
+// Envelopes are byte-for-byte compatible with the license server's
+// crypto.SignJSON: the payload is json.Marshal of the license data, the
+// signature is Ed25519 over those exact bytes, and both are base64-encoded
+// alongside the public key. Use it to generate test or emergency licenses; keep
+// production signing keys on the license server.
+//
+//	s, err := signer.LoadKeyFile("signing-key.json")
+//	file, err := s.Sign(cnwlicense.OfflineLicenseData{
+//	    LicenseKey: "CNW-XXXX-YYYY-ZZZZ",
+//	    Plan:       "enterprise",
+//	    IssuedAt:   time.Now(),
+//	    ExpiresAt:  time.Now().AddDate(0, 0, 30),
+//	})
+package signer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// ErrKeyFileInvalid is returned when a key file is malformed or its public key
+// does not match its private key.
+var ErrKeyFileInvalid = errors.New("invalid signing key file")
+
+// Signer signs offline license files and revocation lists with an Ed25519 key.
+type Signer struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+}
+
+// Option configures a Signer.
+type Option func(*Signer)
+
+// WithKeyID sets the key ID written to signed envelopes, which lets validators
+// with several trusted keys (see cnwlicense.WithTrustedKeys) select this one.
+func WithKeyID(id string) Option {
+	return func(s *Signer) {
+		s.keyID = id
+	}
+}
+
+// New creates a Signer for an existing Ed25519 private key.
+func New(privateKey ed25519.PrivateKey, opts ...Option) *Signer {
+	s := &Signer{privateKey: privateKey}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Generate creates a Signer with a new random Ed25519 key.
+func Generate(opts ...Option) (*Signer, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	return New(priv, opts...), nil
+}
+
+// KeyID returns the key ID set with WithKeyID, or "" if none.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the base64-encoded Ed25519 public key, as accepted by
+// cnwlicense.WithTrustedPublicKey.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.privateKey.Public().(ed25519.PublicKey))
+}
+
+// TrustedKey returns the signer's public key as a keyring entry for
+// cnwlicense.WithTrustedKeys.
+func (s *Signer) TrustedKey() cnwlicense.TrustedKey {
+	return cnwlicense.TrustedKey{ID: s.keyID, PublicKey: s.PublicKey()}
+}
+
+// Sign signs data and returns the license file envelope. Marshal it with
+// json.Marshal to produce a file readable by OfflineValidator.VerifyFile; do
+// not use json.MarshalIndent, which re-indents the signed license bytes and
+// invalidates the signature.
+func (s *Signer) Sign(data cnwlicense.OfflineLicenseData) (*cnwlicense.OfflineLicenseFile, error) {
+	payload, signature, err := s.signJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal license data: %w", err)
+	}
+	return &cnwlicense.OfflineLicenseFile{
+		License:   payload,
+		Signature: signature,
+		PublicKey: s.PublicKey(),
+		KeyID:     s.keyID,
+	}, nil
+}
+
+// SignRevocationList signs list and returns the revocation list envelope, as
+// loaded by OfflineValidator.LoadRevocationList. As with Sign, marshal it with
+// json.Marshal.
+func (s *Signer) SignRevocationList(list cnwlicense.RevocationList) (*cnwlicense.RevocationListFile, error) {
+	payload, signature, err := s.signJSON(list)
+	if err != nil {
+		return nil, fmt.Errorf("marshal revocation list: %w", err)
+	}
+	return &cnwlicense.RevocationListFile{
+		RevocationList: payload,
+		Signature:      signature,
+		PublicKey:      s.PublicKey(),
+		KeyID:          s.keyID,
+	}, nil
+}
+
+// signJSON marshals v and signs the resulting bytes, like the server's
+// crypto.SignJSON.
+func (s *Signer) signJSON(v any) (json.RawMessage, string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+	signature := ed25519.Sign(s.privateKey, payload)
+	return payload, base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// keyFile is the on-disk format of a signing key.
+type keyFile struct {
+	KeyID      string `json:"key_id,omitempty"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"` // base64 Ed25519 private key (or 32-byte seed)
+}
+
+// LoadKeyFile reads a signing key written by SaveKeyFile.
+func LoadKeyFile(path string) (*Signer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	var kf keyFile
+	if err := json.Unmarshal(raw, &kf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFileInvalid, err)
+	}
+	keyBytes, err := base64.StdEncoding.DecodeString(kf.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: private key: %v", ErrKeyFileInvalid, err)
+	}
+	var priv ed25519.PrivateKey
+	switch len(keyBytes) {
+	case ed25519.PrivateKeySize:
+		priv = ed25519.PrivateKey(keyBytes)
+	case ed25519.SeedSize:
+		priv = ed25519.NewKeyFromSeed(keyBytes)
+	default:
+		return nil, fmt.Errorf("%w: private key length %d", ErrKeyFileInvalid, len(keyBytes))
+	}
+
+	s := New(priv, WithKeyID(kf.KeyID))
+	if kf.PublicKey != "" && kf.PublicKey != s.PublicKey() {
+		return nil, fmt.Errorf("%w: public key does not match private key", ErrKeyFileInvalid)
+	}
+	return s, nil
+}
+
+// SaveKeyFile writes the signer's key to path with owner-only permissions,
+// replacing any existing file. The key is written to a temporary file that is
+// renamed into place, so a replaced file never keeps looser permissions.
+func (s *Signer) SaveKeyFile(path string) error {
+	raw, err := json.MarshalIndent(keyFile{
+		KeyID:      s.keyID,
+		PublicKey:  s.PublicKey(),
+		PrivateKey: base64.StdEncoding.EncodeToString(s.privateKey),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// CreateTemp creates the file with mode 0600.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write key file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write key file: %w", err)
+	}
+	return nil
+}
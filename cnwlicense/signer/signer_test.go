@@ -0,0 +1,154 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+func testLicense() cnwlicense.OfflineLicenseData {
+	return cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-TEST-1234",
+		CompanyID:  "comp-001",
+		AppID:      "app-001",
+		Plan:       "enterprise",
+		Features:   cnwlicense.Features{"max_nodes": float64(10)},
+		ExpiresAt:  time.Now().Add(24 * time.Hour).UTC(),
+		IssuedAt:   time.Now().UTC(),
+	}
+}
+
+func TestSigner_Sign(t *testing.T) {
+	s, err := Generate(WithKeyID("2026-01"))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data := testLicense()
+	file, err := s.Sign(data)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// The envelope matches crypto.SignJSON: signature over json.Marshal(data).
+	want, _ := json.Marshal(data)
+	if !bytes.Equal(file.License, want) {
+		t.Errorf("license payload differs from json.Marshal(data):\n%s\n%s", file.License, want)
+	}
+	pub, _ := base64.StdEncoding.DecodeString(file.PublicKey)
+	sig, _ := base64.StdEncoding.DecodeString(file.Signature)
+	if !ed25519.Verify(pub, want, sig) {
+		t.Error("signature does not verify over the payload")
+	}
+	if file.KeyID != "2026-01" {
+		t.Errorf("expected key id 2026-01, got %q", file.KeyID)
+	}
+
+	raw, _ := json.Marshal(file)
+	v := cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedKeys(s.TrustedKey()))
+	got, err := v.Verify(raw)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got.LicenseKey != data.LicenseKey || got.VerifiedKeyID != "2026-01" {
+		t.Errorf("unexpected license: %+v", got)
+	}
+
+	// A different key is not trusted.
+	other, _ := Generate()
+	v = cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(other.PublicKey()))
+	if _, err := v.Verify(raw); !errors.Is(err, cnwlicense.ErrSignatureInvalid) {
+		t.Errorf("expected ErrSignatureInvalid, got %v", err)
+	}
+}
+
+func TestSigner_SignRevocationList(t *testing.T) {
+	s, _ := Generate()
+	file, err := s.SignRevocationList(cnwlicense.RevocationList{
+		IssuedAt: time.Now().UTC(),
+		Revoked:  []cnwlicense.RevokedLicense{{LicenseKey: "CNW-TEST-1234", RevokedAt: time.Now().Add(-time.Hour).UTC()}},
+	})
+	if err != nil {
+		t.Fatalf("sign revocation list: %v", err)
+	}
+	listJSON, _ := json.Marshal(file)
+	license, _ := s.Sign(testLicense())
+	licenseJSON, _ := json.Marshal(license)
+
+	v := cnwlicense.NewOfflineValidator(
+		cnwlicense.WithTrustedPublicKey(s.PublicKey()),
+		cnwlicense.WithRevocationList(listJSON),
+	)
+	if _, err := v.Verify(licenseJSON); !errors.Is(err, cnwlicense.ErrLicenseRevoked) {
+		t.Errorf("expected ErrLicenseRevoked, got %v", err)
+	}
+}
+
+func TestKeyFile_RoundTrip(t *testing.T) {
+	s, _ := Generate(WithKeyID("k1"))
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := s.SaveKeyFile(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v (%v)", fi.Mode().Perm(), err)
+	}
+
+	// Replacing a file with looser permissions tightens them.
+	os.Chmod(path, 0o644)
+	if err := s.SaveKeyFile(path); err != nil {
+		t.Fatalf("save over existing file: %v", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600 after replacing a 0644 file, got %v (%v)", fi.Mode().Perm(), err)
+	}
+
+	loaded, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.KeyID() != "k1" || loaded.PublicKey() != s.PublicKey() {
+		t.Errorf("loaded key differs: %s/%s, want %s/%s", loaded.KeyID(), loaded.PublicKey(), "k1", s.PublicKey())
+	}
+}
+
+func TestLoadKeyFile_Invalid(t *testing.T) {
+	s, _ := Generate()
+	other, _ := Generate()
+	seed := base64.StdEncoding.EncodeToString(s.privateKey.Seed())
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"seed only", `{"private_key":"` + seed + `"}`, false},
+		{"not json", `not json`, true},
+		{"bad base64", `{"private_key":"!!"}`, true},
+		{"bad length", `{"private_key":"AAAA"}`, true},
+		{"mismatched public key", `{"public_key":"` + other.PublicKey() + `","private_key":"` + seed + `"}`, true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".json")
+			os.WriteFile(path, []byte(tt.content), 0o600)
+			loaded, err := LoadKeyFile(path)
+			if tt.wantErr {
+				if !errors.Is(err, ErrKeyFileInvalid) {
+					t.Errorf("expected ErrKeyFileInvalid, got %v", err)
+				}
+				return
+			}
+			if err != nil || loaded.PublicKey() != s.PublicKey() {
+				t.Errorf("expected key from seed, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package cnwlicense
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// StatusChange describes a transition of the Manager's license state.
+type StatusChange struct {
+	From LicenseStatus
+	To   LicenseStatus
+
+	// Previous and Current are the results before and after the transition.
+	// Previous is nil for the first transition out of StatusUnknown. When the
+	// validation failed, Current has Valid false and only LicenseKey and Status set,
+	// except when the server was unreachable: Current then remains the last valid
+	// result, so that the license can still be enforced during an outage. Each
+	// subscriber receives its own copies.
+	Previous *LicenseInfo
+	Current  *LicenseInfo
+
+	// Err is the validation error that caused the transition, if any.
+	Err error
+}
+
+// subscriber is a registered state change callback.
+type subscriber struct {
+	fn func(StatusChange)
+}
+
+// WithExpiringThreshold makes the Manager report a valid license as StatusExpiring
+// once it expires within d. Default: 0 (never).
+func WithExpiringThreshold(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.expiringThreshold = d
+	}
+}
+
+// Subscribe registers fn to be called whenever the Manager's license state changes
+// as a result of ValidateAndEnforce or ValidateOffline. Callbacks run synchronously
+// on the validating goroutine, in subscription order, so they should return quickly.
+// Changes are delivered one at a time and in the order the states were recorded,
+// even when validations run concurrently; callbacks may call Status and Current
+// but must not validate through the same Manager, which would deadlock.
+// The returned function removes the subscription.
+func (m *Manager) Subscribe(fn func(StatusChange)) (unsubscribe func()) {
+	s := &subscriber{fn: fn}
+	m.stateMu.Lock()
+	m.subscribers = append(m.subscribers, s)
+	m.stateMu.Unlock()
+
+	return func() {
+		m.stateMu.Lock()
+		defer m.stateMu.Unlock()
+		for i, other := range m.subscribers {
+			if other == s {
+				m.subscribers = append(m.subscribers[:i:i], m.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Status returns the Manager's current license state.
+func (m *Manager) Status() LicenseStatus {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	return m.state
+}
+
+// Current returns a copy of the result of the most recent validation, or nil if
+// there has been none. While the server is unreachable, it is the last valid result.
+func (m *Manager) Current() *LicenseInfo {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	return m.current.clone()
+}
+
+// observe records the outcome of a validation, fills in ExpiresIn and notifies
+// subscribers if the license state changed, and the expiry warning handler if a
+// warning threshold was crossed. A validation canceled by the caller is not recorded.
+// When the server is unreachable, only the state changes: the last valid result
+// stays current.
+func (m *Manager) observe(licenseKey string, info *LicenseInfo, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		info = &LicenseInfo{LicenseKey: licenseKey, Status: statusForError(err)}
+	} else {
+		if info.ExpiresAt != nil {
+			info.ExpiresIn = info.ExpiresAt.Sub(m.clock.Now())
+		}
+		if info.Valid && info.Status == StatusValid && m.expiring(info) {
+			info.Status = StatusExpiring
+		}
+	}
+	// Keep a private copy so callers modifying their result cannot alter the state.
+	info = info.clone()
+
+	// Hold notifyMu until the notifications are delivered, so that concurrent
+	// validations cannot deliver their changes out of order.
+	m.notifyMu.Lock()
+	defer m.notifyMu.Unlock()
+
+	m.stateMu.Lock()
+	change := StatusChange{
+		From:     m.state,
+		To:       info.Status,
+		Previous: m.current,
+		Current:  info,
+		Err:      err,
+	}
+	m.state = info.Status
+	if info.Status == StatusUnreachable && m.current != nil && m.current.Valid {
+		change.Current = m.current
+	} else {
+		m.current = info
+	}
+	var subs []*subscriber
+	if change.From != change.To {
+		subs = append(subs, m.subscribers...)
+	}
+	warning := m.expiryWarning(info)
+	m.stateMu.Unlock()
+
+	// Each callback gets its own copies, so none can alter the recorded state.
+	for _, s := range subs {
+		c := change
+		c.Previous, c.Current = change.Previous.clone(), change.Current.clone()
+		s.fn(c)
+	}
+	if warning != nil {
+		warning.Info = warning.Info.clone()
+		m.onExpiryWarning(*warning)
+	}
+}
+
+// clone returns a deep copy of info, or nil if info is nil.
+func (info *LicenseInfo) clone() *LicenseInfo {
+	if info == nil {
+		return nil
+	}
+	c := *info
+	c.Features, _ = cloneValue(info.Features).(Features)
+	c.ExpiresAt = cloneTime(info.ExpiresAt)
+	c.ValidatedAt = cloneTime(info.ValidatedAt)
+	c.GraceEndsAt = cloneTime(info.GraceEndsAt)
+	return &c
+}
+
+// cloneValue deep-copies the maps and slices of a decoded JSON value.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Features:
+		if v == nil {
+			return v
+		}
+		c := make(Features, len(v))
+		for k, val := range v {
+			c[k] = cloneValue(val)
+		}
+		return c
+	case map[string]interface{}:
+		return map[string]interface{}(cloneValue(Features(v)).(Features))
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		c := make([]interface{}, len(v))
+		for i, val := range v {
+			c[i] = cloneValue(val)
+		}
+		return c
+	}
+	return v
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// expiring reports whether info expires within the expiring threshold.
+func (m *Manager) expiring(info *LicenseInfo) bool {
+	if m.expiringThreshold <= 0 || info.ExpiresAt == nil {
+		return false
+	}
+	return info.ExpiresIn <= m.expiringThreshold
+}
+
+// rejectedStatus returns the state for a validation response that does not
+// describe a usable license. A suspended or revoked license is reported as such
+// even if it has also expired.
+func (m *Manager) rejectedStatus(resp *ValidateResponse) LicenseStatus {
+	reason := strings.ToLower(resp.Reason)
+	switch {
+	case strings.Contains(reason, "suspended"), strings.Contains(reason, "revoked"),
+		strings.Contains(reason, "inactive"):
+		return StatusRevoked
+	case strings.Contains(reason, "expired"):
+		return StatusExpired
+	case resp.ExpiresAt != nil && resp.ExpiresAt.Before(m.clock.Now()):
+		return StatusExpired
+	}
+	return StatusInvalid
+}
+
+// statusForError returns the state for a failed validation.
+func statusForError(err error) LicenseStatus {
+	switch {
+	case errors.Is(err, ErrLicenseExpired):
+		return StatusExpired
+	case errors.Is(err, ErrLicenseRevoked), errors.Is(err, ErrLicenseInactive):
+		return StatusRevoked
+	case isUnavailable(err):
+		return StatusUnreachable
+	}
+	return StatusInvalid
+}
@@ -0,0 +1,288 @@
+package cnwlicense_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudNativeWorks/cnw-license-sdk/cnwlicense"
+)
+
+// scriptedLicenseServer serves the validate response returned by next, or a 503
+// when next returns nil.
+func scriptedLicenseServer(t *testing.T, next func() *cnwlicense.ValidateResponse) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := next()
+		if resp == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestManager_Status_Transitions(t *testing.T) {
+	future := time.Now().Add(30 * 24 * time.Hour).UTC()
+	past := time.Now().Add(-time.Hour).UTC()
+	var current atomic.Pointer[cnwlicense.ValidateResponse]
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse { return current.Load() })
+
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	if got := mgr.Status(); got != cnwlicense.StatusUnknown {
+		t.Fatalf("expected initial status unknown, got %s", got)
+	}
+	if mgr.Current() != nil {
+		t.Fatal("expected no current result before the first validation")
+	}
+
+	var changes []cnwlicense.StatusChange
+	mgr.Subscribe(func(c cnwlicense.StatusChange) { changes = append(changes, c) })
+
+	steps := []struct {
+		resp *cnwlicense.ValidateResponse
+		want cnwlicense.LicenseStatus
+	}{
+		{&cnwlicense.ValidateResponse{Valid: true, Plan: "pro", ExpiresAt: &future}, cnwlicense.StatusValid},
+		{&cnwlicense.ValidateResponse{Valid: true, Plan: "pro", ExpiresAt: &future}, cnwlicense.StatusValid}, // no transition
+		{nil, cnwlicense.StatusUnreachable},
+		{&cnwlicense.ValidateResponse{Valid: false, Reason: "license is suspended"}, cnwlicense.StatusRevoked},
+		{&cnwlicense.ValidateResponse{Valid: false, Reason: "license expired", ExpiresAt: &past}, cnwlicense.StatusExpired},
+		{&cnwlicense.ValidateResponse{Valid: false, Reason: "license not found"}, cnwlicense.StatusInvalid},
+	}
+	for _, step := range steps {
+		current.Store(step.resp)
+		mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+		if got := mgr.Status(); got != step.want {
+			t.Fatalf("expected status %s, got %s", step.want, got)
+		}
+	}
+
+	want := []cnwlicense.LicenseStatus{cnwlicense.StatusValid, cnwlicense.StatusUnreachable, cnwlicense.StatusRevoked, cnwlicense.StatusExpired, cnwlicense.StatusInvalid}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d transitions, got %d: %+v", len(want), len(changes), changes)
+	}
+	for i, c := range changes {
+		if c.To != want[i] {
+			t.Errorf("transition %d: expected to=%s, got to=%s", i, want[i], c.To)
+		}
+	}
+
+	first := changes[0]
+	if first.From != cnwlicense.StatusUnknown || first.Previous != nil || !first.Current.Valid || first.Current.Plan != "pro" {
+		t.Errorf("unexpected first transition: %+v", first)
+	}
+	unreachable := changes[1]
+	if unreachable.From != cnwlicense.StatusValid || unreachable.Previous.Plan != "pro" || unreachable.Err == nil {
+		t.Errorf("expected transition from valid with error, got %+v", unreachable)
+	}
+	if !unreachable.Current.Valid || unreachable.Current.Plan != "pro" {
+		t.Errorf("expected last valid result to stay current while unreachable, got %+v", unreachable.Current)
+	}
+	revoked := changes[2]
+	if revoked.Current.Valid || revoked.Current.Status != cnwlicense.StatusRevoked || revoked.Current.LicenseKey != "CNW-STATE" {
+		t.Errorf("expected invalid current info for rejected license, got %+v", revoked.Current)
+	}
+}
+
+func TestManager_Status_UnreachableKeepsCurrent(t *testing.T) {
+	var up atomic.Bool
+	up.Store(true)
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		if !up.Load() {
+			return nil
+		}
+		return &cnwlicense.ValidateResponse{Valid: true, Plan: "pro"}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+
+	mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+	up.Store(false)
+	if _, err := mgr.ValidateAndEnforce(context.Background(), "CNW-STATE"); err == nil {
+		t.Fatal("expected an error while the server is down")
+	}
+	if got := mgr.Status(); got != cnwlicense.StatusUnreachable {
+		t.Errorf("expected status unreachable, got %s", got)
+	}
+	if cur := mgr.Current(); cur == nil || !cur.Valid || cur.Plan != "pro" {
+		t.Errorf("expected last valid result to stay current, got %+v", cur)
+	}
+}
+
+func TestManager_Subscribe_Ordered(t *testing.T) {
+	var n atomic.Int32
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: n.Add(1)%2 == 0, Reason: "license not found"}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+
+	var mu sync.Mutex
+	var changes []cnwlicense.StatusChange
+	mgr.Subscribe(func(c cnwlicense.StatusChange) {
+		time.Sleep(time.Millisecond) // widen the window for out-of-order delivery
+		mu.Lock()
+		changes = append(changes, c)
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+		}()
+	}
+	wg.Wait()
+
+	from := cnwlicense.StatusUnknown
+	for i, c := range changes {
+		if c.From != from {
+			t.Fatalf("change %d: expected from=%s, got %s", i, from, c.From)
+		}
+		from = c.To
+	}
+	if from != mgr.Status() {
+		t.Errorf("expected last change to end in %s, got %s", mgr.Status(), from)
+	}
+}
+
+func TestManager_Status_Expiring(t *testing.T) {
+	soon := time.Now().Add(48 * time.Hour).UTC()
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true, ExpiresAt: &soon}
+	})
+	client := cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))
+
+	info, err := cnwlicense.NewManager(cnwlicense.WithOnlineClient(client)).ValidateAndEnforce(context.Background(), "CNW-STATE")
+	if err != nil || info.Status != cnwlicense.StatusValid {
+		t.Fatalf("expected status valid without threshold, got %v, %v", info, err)
+	}
+
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(client), cnwlicense.WithExpiringThreshold(7*24*time.Hour))
+	info, err = mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Valid || info.Status != cnwlicense.StatusExpiring || mgr.Status() != cnwlicense.StatusExpiring {
+		t.Errorf("expected valid expiring license, got valid=%v status=%s state=%s", info.Valid, info.Status, mgr.Status())
+	}
+}
+
+func TestManager_Status_OfflineExpired(t *testing.T) {
+	path, pub := writeOfflineLicense(t, cnwlicense.OfflineLicenseData{
+		LicenseKey: "CNW-STATE",
+		ExpiresAt:  time.Now().Add(-time.Hour),
+		IssuedAt:   time.Now().Add(-24 * time.Hour),
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOfflineValidator(cnwlicense.NewOfflineValidator(cnwlicense.WithTrustedPublicKey(pub))),
+		cnwlicense.WithOfflineLicenseFile(path),
+	)
+	var got cnwlicense.StatusChange
+	mgr.Subscribe(func(c cnwlicense.StatusChange) { got = c })
+
+	if _, err := mgr.ValidateOffline("CNW-STATE"); !errors.Is(err, cnwlicense.ErrLicenseExpired) {
+		t.Fatalf("expected ErrLicenseExpired, got %v", err)
+	}
+	if got.To != cnwlicense.StatusExpired || !errors.Is(got.Err, cnwlicense.ErrLicenseExpired) {
+		t.Errorf("expected transition to expired, got %+v", got)
+	}
+}
+
+func TestManager_Subscribe_Unsubscribe(t *testing.T) {
+	var valid atomic.Bool
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: valid.Load(), Reason: "license not found"}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+
+	var a, b int
+	unsubA := mgr.Subscribe(func(cnwlicense.StatusChange) { a++ })
+	mgr.Subscribe(func(cnwlicense.StatusChange) { b++ })
+
+	valid.Store(true)
+	mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+	unsubA()
+	unsubA() // idempotent
+	valid.Store(false)
+	mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+
+	if a != 1 || b != 2 {
+		t.Errorf("expected a=1 b=2, got a=%d b=%d", a, b)
+	}
+}
+
+func TestManager_Current_IsCopy(t *testing.T) {
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true, Plan: "pro"}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+
+	info, _ := mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+	info.Plan = "modified"
+	if got := mgr.Current().Plan; got != "pro" {
+		t.Errorf("expected stored plan pro, got %s", got)
+	}
+}
+
+func TestManager_Subscribe_ReceivesCopies(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour).UTC()
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true, Plan: "pro", ExpiresAt: &expiresAt, Features: cnwlicense.Features{
+			"sso": true, "regions": []interface{}{"eu"},
+		}}
+	})
+	mgr := cnwlicense.NewManager(
+		cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))),
+		cnwlicense.WithExpiryWarnings(func(w cnwlicense.ExpiryWarning) {
+			w.Info.Plan = "changed by warning handler"
+			w.Info.Features["sso"] = false
+		}, 7*24*time.Hour),
+	)
+	mgr.Subscribe(func(c cnwlicense.StatusChange) {
+		c.Current.Plan = "changed by subscriber"
+		c.Current.Features["regions"].([]interface{})[0] = "us"
+		*c.Current.ExpiresAt = time.Time{}
+	})
+	mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+
+	cur := mgr.Current()
+	if cur.Plan != "pro" || !cur.Features.Bool("sso", false) || cur.Features["regions"].([]interface{})[0] != "eu" ||
+		!cur.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected callbacks not to alter the recorded state, got %+v", cur)
+	}
+}
+
+func TestManager_Status_Concurrent(t *testing.T) {
+	server := scriptedLicenseServer(t, func() *cnwlicense.ValidateResponse {
+		return &cnwlicense.ValidateResponse{Valid: true}
+	})
+	mgr := cnwlicense.NewManager(cnwlicense.WithOnlineClient(cnwlicense.NewOnlineClient(server.URL, "test-key", cnwlicense.WithFingerprint("node-fp"))))
+	var changes atomic.Int32
+	mgr.Subscribe(func(cnwlicense.StatusChange) { changes.Add(1) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mgr.ValidateAndEnforce(context.Background(), "CNW-STATE")
+			mgr.Status()
+			mgr.Current()
+		}()
+	}
+	wg.Wait()
+	if got := changes.Load(); got != 1 {
+		t.Errorf("expected exactly 1 transition, got %d", got)
+	}
+}
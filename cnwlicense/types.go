@@ -17,12 +17,16 @@ type ValidateRequest struct {
 // ValidateResponse is the response from the /v1/validate endpoint.
 // The server returns this directly (not wrapped in {data: ...}).
 type ValidateResponse struct {
-	Valid               bool                   `json:"valid"`
-	Reason              string                 `json:"reason,omitempty"`
-	Plan                string                 `json:"plan,omitempty"`
-	ExpiresAt           *time.Time             `json:"expires_at,omitempty"`
-	Features            map[string]interface{} `json:"features,omitempty"`
-	ActivationRemaining int                    `json:"activation_remaining"`
+	Valid               bool       `json:"valid"`
+	Reason              string     `json:"reason,omitempty"`
+	Plan                string     `json:"plan,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	Features            Features   `json:"features,omitempty"`
+	ActivationRemaining int        `json:"activation_remaining"`
+
+	// DegradedFeatures optionally replaces Features while an expired license is
+	// within its post-expiry grace period (see WithManagerExpiryGrace).
+	DegradedFeatures Features `json:"degraded_features,omitempty"`
 }
 
 // ActivateRequest is the request body for the /v1/activate endpoint.
@@ -48,38 +52,127 @@ type ActivateResponse struct {
 	ActivatedAt time.Time              `json:"activated_at"`
 	LastSeenAt  time.Time              `json:"last_seen_at"`
 	Plan        string                 `json:"plan,omitempty"`
-	Features    map[string]interface{} `json:"features,omitempty"`
+	Features    Features               `json:"features,omitempty"`
+}
+
+// DeactivateRequest is the request body for the /v1/deactivate endpoint.
+// Identify the activation either by ActivationID, or by LicenseKey and Fingerprint.
+type DeactivateRequest struct {
+	ActivationID string `json:"activation_id,omitempty"`
+	LicenseKey   string `json:"license_key,omitempty"`
+	Fingerprint  string `json:"fingerprint,omitempty"`
+}
+
+// DeactivateResponse is the result of releasing an activation slot.
+// The server wraps this in {data: ...} via the Success() helper.
+type DeactivateResponse struct {
+	ID                  string    `json:"id"`
+	LicenseID           string    `json:"license_id"`
+	Fingerprint         string    `json:"fingerprint"`
+	DeactivatedAt       time.Time `json:"deactivated_at"`
+	ActivationRemaining int       `json:"activation_remaining"`
+}
+
+// HeartbeatRequest is the request body for the /v1/heartbeat endpoint.
+// Identify the activation either by ActivationID, or by LicenseKey and Fingerprint.
+type HeartbeatRequest struct {
+	ActivationID string `json:"activation_id,omitempty"`
+	LicenseKey   string `json:"license_key,omitempty"`
+	Fingerprint  string `json:"fingerprint,omitempty"`
+}
+
+// HeartbeatResponse is the refreshed activation state returned by the server.
+// The server wraps this in {data: ...} via the Success() helper.
+type HeartbeatResponse struct {
+	ID          string    `json:"id"`
+	LicenseID   string    `json:"license_id"`
+	Fingerprint string    `json:"fingerprint"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
 }
 
 // OfflineLicenseFile represents the JSON structure of a signed offline license file.
 // The License field is kept as json.RawMessage to preserve the exact bytes for
 // signature verification (matching server's crypto.SignJSON behavior).
+// KeyID optionally names the signing key so a validator with several trusted keys
+// (see WithTrustedKeys) can select the right one.
 type OfflineLicenseFile struct {
 	License   json.RawMessage `json:"license"`
 	Signature string          `json:"signature"`
 	PublicKey string          `json:"public_key"`
+	KeyID     string          `json:"key_id,omitempty"`
 }
 
 // OfflineLicenseData contains the license information embedded in an offline license file.
 // Matches api/internal/service/offline_service.go.
 type OfflineLicenseData struct {
-	LicenseKey string                 `json:"license_key"`
-	CompanyID  string                 `json:"company_id"`
-	AppID      string                 `json:"app_id"`
-	Plan       string                 `json:"plan"`
-	Features   map[string]interface{} `json:"features"`
-	ExpiresAt  time.Time              `json:"expires_at"`
-	IssuedAt   time.Time              `json:"issued_at"`
+	LicenseKey string    `json:"license_key"`
+	CompanyID  string    `json:"company_id"`
+	AppID      string    `json:"app_id"`
+	Plan       string    `json:"plan"`
+	Features   Features  `json:"features"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	IssuedAt   time.Time `json:"issued_at"`
+
+	// NotBefore optionally sets when the license starts to be valid, allowing
+	// renewals to be provisioned ahead of the contract start. Nil means immediately.
+	NotBefore *time.Time `json:"not_before,omitempty"`
+
+	// Fingerprints optionally node-locks the license to the listed machine
+	// fingerprints (as produced by GenerateFingerprint). Empty means any machine.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	// ActivationNonce is the nonce of the offline activation request this license
+	// answers (see VerifyActivationResponse). Empty for regular license files.
+	ActivationNonce string `json:"activation_nonce,omitempty"`
+
+	// DegradedFeatures optionally replaces Features during the post-expiry grace
+	// period (see WithExpiryGrace and EffectiveFeatures).
+	DegradedFeatures Features `json:"degraded_features,omitempty"`
+
+	// VerifiedKeyID is the ID of the trusted key that verified the signature.
+	// It is set by OfflineValidator and is not part of the signed payload.
+	VerifiedKeyID string `json:"-"`
+
+	// Status and GraceRemaining are set by OfflineValidator: StatusGrace while an
+	// expired license is within the validator's expiry grace period.
+	Status         LicenseStatus `json:"-"`
+	GraceRemaining time.Duration `json:"-"`
 }
 
+// LicenseSource identifies where a LicenseInfo result came from.
+type LicenseSource string
+
+const (
+	SourceOnline  LicenseSource = "online"  // answered by the license server
+	SourceOffline LicenseSource = "offline" // read from a signed offline license file
+	SourceCache   LicenseSource = "cache"   // last-known-good result, server unreachable
+)
+
 // LicenseInfo is the unified result returned by the Manager after validation and enforcement.
 type LicenseInfo struct {
-	Valid       bool                   `json:"valid"`
-	LicenseKey  string                 `json:"license_key"`
-	Plan        string                 `json:"plan,omitempty"`
-	Features    map[string]interface{} `json:"features,omitempty"`
-	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
-	Fingerprint string                 `json:"fingerprint"`
+	Valid       bool          `json:"valid"`
+	LicenseKey  string        `json:"license_key"`
+	Plan        string        `json:"plan,omitempty"`
+	Features    Features      `json:"features,omitempty"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty"`
+	Fingerprint string        `json:"fingerprint"`
+	Source      LicenseSource `json:"source,omitempty"`
+
+	// ValidatedAt and GraceEndsAt are set when the result was served from the
+	// last-known-good cache: when the server last confirmed the license, and
+	// when the cached answer stops being honored.
+	ValidatedAt *time.Time `json:"validated_at,omitempty"`
+	GraceEndsAt *time.Time `json:"grace_ends_at,omitempty"`
+
+	// Status is StatusGrace while an expired license is honored during the
+	// post-expiry grace period (see WithManagerExpiryGrace), with GraceRemaining
+	// left until it is rejected. Features then holds the degraded feature set.
+	Status         LicenseStatus `json:"status,omitempty"`
+	GraceRemaining time.Duration `json:"grace_remaining,omitempty"`
+
+	// ExpiresIn is the time left until ExpiresAt when the result was produced
+	// (negative during the post-expiry grace period). Zero if ExpiresAt is nil.
+	ExpiresIn time.Duration `json:"expires_in,omitempty"`
 }
 
 // HardwareLimits holds the hardware constraints extracted from a license's features map.